@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// ACLInfo is a single ACL binding
+type ACLInfo struct {
+	ResourceType   string `json:"resource_type"`
+	ResourceName   string `json:"resource_name"`
+	PatternType    string `json:"pattern_type"`
+	Principal      string `json:"principal"`
+	Host           string `json:"host"`
+	Operation      string `json:"operation"`
+	PermissionType string `json:"permission_type"`
+}
+
+// fetchACLs lists all ACL bindings in the cluster.
+// Clusters without an authorizer return an error, which is logged and treated as no ACLs.
+func fetchACLs(admin sarama.ClusterAdmin) []ACLInfo {
+	filter := sarama.AclFilter{
+		Version:                   1,
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		Operation:                 sarama.AclOperationAny,
+		PermissionType:            sarama.AclPermissionAny,
+	}
+
+	resources, err := admin.ListAcls(filter)
+	if err != nil {
+		log.Printf("Warning: Could not list ACLs (is an authorizer configured?): %v", err)
+		return nil
+	}
+
+	acls := make([]ACLInfo, 0)
+	for _, resource := range resources {
+		for _, acl := range resource.Acls {
+			acls = append(acls, ACLInfo{
+				ResourceType:   resource.ResourceType.String(),
+				ResourceName:   resource.ResourceName,
+				PatternType:    resource.ResourcePatternType.String(),
+				Principal:      acl.Principal,
+				Host:           acl.Host,
+				Operation:      acl.Operation.String(),
+				PermissionType: acl.PermissionType.String(),
+			})
+		}
+	}
+
+	sort.Slice(acls, func(i, j int) bool {
+		a, b := acls[i], acls[j]
+		if a.Principal != b.Principal {
+			return a.Principal < b.Principal
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		if a.ResourceName != b.ResourceName {
+			return a.ResourceName < b.ResourceName
+		}
+		return a.Operation < b.Operation
+	})
+
+	return acls
+}
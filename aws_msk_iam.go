@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// MSK IAM signing constants, matching the aws-msk-iam-sasl-signer libraries
+const (
+	mskIAMService     = "kafka-cluster"
+	mskIAMAction      = "kafka-cluster:Connect"
+	mskIAMTokenExpiry = 15 * time.Minute
+	mskIAMUserAgent   = "kmap"
+)
+
+// AWSCredentials are the static or temporary credentials used for SigV4 signing
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero for long-lived credentials
+	Source          string
+}
+
+// expired reports whether temporary credentials are about to expire
+func (c AWSCredentials) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && now.Add(time.Minute).After(c.Expires)
+}
+
+// presignMSKConnectURL builds the SigV4 query-signed kafka-cluster:Connect URL used as the MSK IAM token.
+// It does no I/O, so it can be checked against fixed credentials and times.
+func presignMSKConnectURL(creds AWSCredentials, region string, now time.Time) (string, error) {
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return "", fmt.Errorf("AWS credentials are incomplete")
+	}
+	if region == "" {
+		return "", fmt.Errorf("AWS region is required")
+	}
+
+	host := fmt.Sprintf("kafka.%s.amazonaws.com", region)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	shortDate := now.UTC().Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", shortDate, region, mskIAMService)
+
+	query := map[string]string{
+		"Action":              mskIAMAction,
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    creds.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       fmt.Sprintf("%d", int(mskIAMTokenExpiry.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if creds.SessionToken != "" {
+		query["X-Amz-Security-Token"] = creds.SessionToken
+	}
+
+	canonicalQuery := awsCanonicalQuery(query)
+	emptyPayloadHash := sha256.Sum256(nil)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		canonicalQuery,
+		"host:" + host + "\n",
+		"host",
+		hex.EncodeToString(emptyPayloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, mskIAMService)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("https://%s/?%s&X-Amz-Signature=%s&User-Agent=%s",
+		host, canonicalQuery, signature, awsURIEncode(mskIAMUserAgent)), nil
+}
+
+// mskIAMToken encodes a presigned URL as an OAUTHBEARER token
+func mskIAMToken(presignedURL string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(presignedURL))
+}
+
+// awsCanonicalQuery returns the query string sorted and encoded as SigV4 requires
+func awsCanonicalQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, awsURIEncode(k)+"="+awsURIEncode(params[k]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes everything except the RFC 3986 unreserved characters
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// mskIAMTokenProvider implements sarama.AccessTokenProvider for MSK IAM over OAUTHBEARER
+type mskIAMTokenProvider struct {
+	region  string
+	profile string
+	resolve func() (AWSCredentials, error)
+	now     func() time.Time
+
+	mu          sync.Mutex
+	creds       AWSCredentials
+	token       string
+	tokenExpiry time.Time
+}
+
+func newMSKIAMTokenProvider(region, profile string) *mskIAMTokenProvider {
+	p := &mskIAMTokenProvider{
+		region:  region,
+		profile: profile,
+		now:     time.Now,
+	}
+	p.resolve = func() (AWSCredentials, error) {
+		return resolveAWSCredentials(profile, region, http.DefaultClient)
+	}
+	return p
+}
+
+// Token returns a cached token, signing a new one shortly before the previous one expires
+func (p *mskIAMTokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.token != "" && now.Before(p.tokenExpiry) {
+		return &sarama.AccessToken{Token: p.token}, nil
+	}
+
+	if p.creds.AccessKeyID == "" || p.creds.expired(now) {
+		creds, err := p.resolve()
+		if err != nil {
+			return nil, err
+		}
+		p.creds = creds
+	}
+
+	signed, err := presignMSKConnectURL(p.creds, p.region, now)
+	if err != nil {
+		return nil, err
+	}
+
+	p.token = mskIAMToken(signed)
+	// Refresh well before the signature expires so reconnects never send a stale token
+	p.tokenExpiry = now.Add(mskIAMTokenExpiry - 5*time.Minute)
+	if !p.creds.Expires.IsZero() && p.creds.Expires.Before(p.tokenExpiry) {
+		p.tokenExpiry = p.creds.Expires
+	}
+
+	return &sarama.AccessToken{Token: p.token}, nil
+}
+
+// configureMSKIAM sets up AWS MSK IAM authentication using SASL/OAUTHBEARER
+func configureMSKIAM(config *sarama.Config, opts SecurityOptions) error {
+	if !config.Net.TLS.Enable {
+		return fmt.Errorf("AWS MSK IAM requires -security-protocol SASL_SSL")
+	}
+
+	region := resolveAWSRegion(opts.AWSRegion, opts.AWSProfile, opts.Brokers)
+	if region == "" {
+		return fmt.Errorf("could not determine the AWS region; set -aws-region or AWS_REGION")
+	}
+
+	provider := newMSKIAMTokenProvider(region, opts.AWSProfile)
+
+	// Resolve credentials now so a missing or invalid chain fails before connecting
+	if _, err := provider.Token(); err != nil {
+		return fmt.Errorf("error obtaining AWS credentials: %v", err)
+	}
+	log.Printf("Using AWS credentials from %s (region %s)", provider.creds.Source, region)
+
+	config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+	config.Net.SASL.TokenProvider = provider
+	return nil
+}
+
+// mskHostRegex extracts the region from MSK broker hostnames such as
+// b-1.cluster.abc123.c2.kafka.us-east-1.amazonaws.com
+var mskHostRegex = regexp.MustCompile(`\.kafka(?:-serverless)?\.([a-z0-9-]+)\.amazonaws\.com`)
+
+// resolveAWSRegion picks the region from the flag, the environment, the shared config profile
+// or the MSK broker hostnames, in that order
+func resolveAWSRegion(flagRegion, profile string, brokers []string) string {
+	if flagRegion != "" {
+		return flagRegion
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+	if section, err := loadAWSProfile(awsConfigFile(), configProfileName(awsProfileName(profile))); err == nil && section["region"] != "" {
+		return section["region"]
+	}
+	for _, broker := range brokers {
+		if m := mskHostRegex.FindStringSubmatch(broker); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// resolveAWSCredentials walks the standard credential chain:
+// environment variables, web identity token (AWS_WEB_IDENTITY_TOKEN_FILE), then the shared profile
+func resolveAWSCredentials(profile, region string, httpClient *http.Client) (AWSCredentials, error) {
+	// Environment variables (ignored when a profile is requested explicitly)
+	if profile == "" {
+		accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+		secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if accessKey != "" && secretKey != "" {
+			return AWSCredentials{
+				AccessKeyID:     accessKey,
+				SecretAccessKey: secretKey,
+				SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+				Source:          "environment",
+			}, nil
+		}
+
+		// Web identity (EKS IRSA, GitHub Actions OIDC, ...)
+		if tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); tokenFile != "" {
+			return assumeRoleWithWebIdentity(httpClient, region, os.Getenv("AWS_ROLE_ARN"), tokenFile, os.Getenv("AWS_ROLE_SESSION_NAME"))
+		}
+	}
+
+	// Shared credentials and config files
+	name := awsProfileName(profile)
+	if section, err := loadAWSProfile(awsCredentialsFile(), name); err == nil && section["aws_access_key_id"] != "" {
+		return awsCredentialsFromProfile(section, "shared credentials file (profile "+name+")"), nil
+	}
+
+	section, err := loadAWSProfile(awsConfigFile(), configProfileName(name))
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("no AWS credentials found in environment, web identity or profile %q", name)
+	}
+	if section["aws_access_key_id"] != "" {
+		return awsCredentialsFromProfile(section, "shared config file (profile "+name+")"), nil
+	}
+	if section["role_arn"] != "" && section["web_identity_token_file"] != "" {
+		if section["region"] != "" && region == "" {
+			region = section["region"]
+		}
+		return assumeRoleWithWebIdentity(httpClient, region, section["role_arn"], section["web_identity_token_file"], section["role_session_name"])
+	}
+
+	return AWSCredentials{}, fmt.Errorf("profile %q has no static or web identity credentials (source_profile and credential_process are not supported)", name)
+}
+
+func awsCredentialsFromProfile(section map[string]string, source string) AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+		Source:          source,
+	}
+}
+
+func awsProfileName(profile string) string {
+	if profile != "" {
+		return profile
+	}
+	if env := os.Getenv("AWS_PROFILE"); env != "" {
+		return env
+	}
+	return "default"
+}
+
+// configProfileName returns the section name used in ~/.aws/config, where non-default profiles are prefixed
+func configProfileName(name string) string {
+	if name == "default" {
+		return name
+	}
+	return "profile " + name
+}
+
+func awsCredentialsFile() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "credentials")
+}
+
+func awsConfigFile() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "config")
+}
+
+// loadAWSProfile reads one section of an AWS INI file
+func loadAWSProfile(filename, section string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	found := false
+	inSection := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			inSection = name == section
+			found = found || inSection
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("profile [%s] not found in %s", section, filename)
+	}
+	return values, nil
+}
+
+// assumeRoleWithWebIdentityResponse is the subset of the STS response kmap needs
+type assumeRoleWithWebIdentityResponse struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+			Expiration      string `xml:"Expiration"`
+		} `xml:"Credentials"`
+	} `xml:"AssumeRoleWithWebIdentityResult"`
+}
+
+// assumeRoleWithWebIdentity exchanges a web identity token for temporary credentials.
+// The STS call is unsigned, so no existing credentials are needed.
+func assumeRoleWithWebIdentity(httpClient *http.Client, region, roleARN, tokenFile, sessionName string) (AWSCredentials, error) {
+	if roleARN == "" {
+		return AWSCredentials{}, fmt.Errorf("web identity token file %s is set but no role ARN (AWS_ROLE_ARN)", tokenFile)
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("error reading web identity token: %v", err)
+	}
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("kmap-%d", time.Now().Unix())
+	}
+
+	endpoint := "https://sts.amazonaws.com/"
+	if region != "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+
+	resp, err := httpClient.PostForm(endpoint, form)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("AssumeRoleWithWebIdentity request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("error reading STS response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return AWSCredentials{}, fmt.Errorf("AssumeRoleWithWebIdentity failed (%s): %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var parsed assumeRoleWithWebIdentityResponse
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return AWSCredentials{}, fmt.Errorf("error parsing STS response: %v", err)
+	}
+
+	c := parsed.Result.Credentials
+	creds := AWSCredentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+		Source:          "web identity (" + roleARN + ")",
+	}
+	if expires, err := time.Parse(time.RFC3339, c.Expiration); err == nil {
+		creds.Expires = expires
+	}
+	return creds, nil
+}
+
+// mskIAMJAASLines returns the properties the Kafka CLI tools need for MSK IAM.
+// The aws-msk-iam-auth jar must be on the CLI classpath (CLASSPATH=/path/to/aws-msk-iam-auth-all.jar).
+func mskIAMJAASLines(config *sarama.Config) []string {
+	jaas := "software.amazon.msk.auth.iam.IAMLoginModule required;"
+	if p, ok := config.Net.SASL.TokenProvider.(*mskIAMTokenProvider); ok && p.profile != "" {
+		jaas = fmt.Sprintf("software.amazon.msk.auth.iam.IAMLoginModule required awsProfileName=\"%s\";", p.profile)
+	}
+	return []string{
+		"sasl.mechanism=AWS_MSK_IAM",
+		"sasl.jaas.config=" + jaas,
+		"sasl.client.callback.handler.class=software.amazon.msk.auth.iam.IAMClientCallbackHandler",
+	}
+}
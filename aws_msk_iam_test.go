@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestPresignMSKConnectURL(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	creds := AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+
+	tests := []struct {
+		name         string
+		sessionToken string
+		query        string
+		signature    string
+		token        string
+	}{
+		{
+			name:      "long-lived credentials",
+			query:     "Action=kafka-cluster%3AConnect&X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIDEXAMPLE%2F20240102%2Fus-east-1%2Fkafka-cluster%2Faws4_request&X-Amz-Date=20240102T030405Z&X-Amz-Expires=900&X-Amz-SignedHeaders=host",
+			signature: "d976a63334b2f60e682ba65032cd9e985cc525f9be809dba495984f866910124",
+			token:     "aHR0cHM6Ly9rYWZrYS51cy1lYXN0LTEuYW1hem9uYXdzLmNvbS8_QWN0aW9uPWthZmthLWNsdXN0ZXIlM0FDb25uZWN0JlgtQW16LUFsZ29yaXRobT1BV1M0LUhNQUMtU0hBMjU2JlgtQW16LUNyZWRlbnRpYWw9QUtJREVYQU1QTEUlMkYyMDI0MDEwMiUyRnVzLWVhc3QtMSUyRmthZmthLWNsdXN0ZXIlMkZhd3M0X3JlcXVlc3QmWC1BbXotRGF0ZT0yMDI0MDEwMlQwMzA0MDVaJlgtQW16LUV4cGlyZXM9OTAwJlgtQW16LVNpZ25lZEhlYWRlcnM9aG9zdCZYLUFtei1TaWduYXR1cmU9ZDk3NmE2MzMzNGIyZjYwZTY4MmJhNjUwMzJjZDllOTg1Y2M1MjVmOWJlODA5ZGJhNDk1OTg0Zjg2NjkxMDEyNCZVc2VyLUFnZW50PWttYXA",
+		},
+		{
+			name:         "session token",
+			sessionToken: "session/token+=",
+			query:        "Action=kafka-cluster%3AConnect&X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIDEXAMPLE%2F20240102%2Fus-east-1%2Fkafka-cluster%2Faws4_request&X-Amz-Date=20240102T030405Z&X-Amz-Expires=900&X-Amz-Security-Token=session%2Ftoken%2B%3D&X-Amz-SignedHeaders=host",
+			signature:    "d0bec7b3cbd64e4f04908f5546c59d05b6c83092690fd23d7df4c75f4853b720",
+			token:        "aHR0cHM6Ly9rYWZrYS51cy1lYXN0LTEuYW1hem9uYXdzLmNvbS8_QWN0aW9uPWthZmthLWNsdXN0ZXIlM0FDb25uZWN0JlgtQW16LUFsZ29yaXRobT1BV1M0LUhNQUMtU0hBMjU2JlgtQW16LUNyZWRlbnRpYWw9QUtJREVYQU1QTEUlMkYyMDI0MDEwMiUyRnVzLWVhc3QtMSUyRmthZmthLWNsdXN0ZXIlMkZhd3M0X3JlcXVlc3QmWC1BbXotRGF0ZT0yMDI0MDEwMlQwMzA0MDVaJlgtQW16LUV4cGlyZXM9OTAwJlgtQW16LVNlY3VyaXR5LVRva2VuPXNlc3Npb24lMkZ0b2tlbiUyQiUzRCZYLUFtei1TaWduZWRIZWFkZXJzPWhvc3QmWC1BbXotU2lnbmF0dXJlPWQwYmVjN2IzY2JkNjRlNGYwNDkwOGY1NTQ2YzU5ZDA1YjZjODMwOTI2OTBmZDIzZDdkZjRjNzVmNDg1M2I3MjAmVXNlci1BZ2VudD1rbWFw",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := creds
+			c.SessionToken = tt.sessionToken
+			signed, err := presignMSKConnectURL(c, "us-east-1", now)
+			if err != nil {
+				t.Fatalf("presignMSKConnectURL: %v", err)
+			}
+
+			want := "https://kafka.us-east-1.amazonaws.com/?" + tt.query + "&X-Amz-Signature=" + tt.signature + "&User-Agent=kmap"
+			if signed != want {
+				t.Errorf("presigned URL\n got: %s\nwant: %s", signed, want)
+			}
+
+			token := mskIAMToken(signed)
+			if token != tt.token {
+				t.Errorf("token\n got: %s\nwant: %s", token, tt.token)
+			}
+			if strings.ContainsAny(token, "+/=") {
+				t.Errorf("token is not unpadded base64url: %s", token)
+			}
+			decoded, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil || string(decoded) != signed {
+				t.Errorf("token does not decode to the presigned URL: %v", err)
+			}
+		})
+	}
+}
+
+func TestPresignMSKConnectURLErrors(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := presignMSKConnectURL(AWSCredentials{AccessKeyID: "AKID"}, "us-east-1", now); err == nil {
+		t.Error("expected an error for a missing secret key")
+	}
+	if _, err := presignMSKConnectURL(AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "", now); err == nil {
+		t.Error("expected an error for a missing region")
+	}
+}
+
+func TestGenericOAuthBearerRejected(t *testing.T) {
+	err := configureSecurity(sarama.NewConfig(), SecurityOptions{Protocol: "SASL_SSL", SASLMechanism: "OAUTHBEARER"})
+	if err == nil || !strings.Contains(err.Error(), "AWS_MSK_IAM") {
+		t.Fatalf("expected OAUTHBEARER to be rejected with a hint, got %v", err)
+	}
+}
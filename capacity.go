@@ -0,0 +1,457 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// maxGrowthContributors limits the top growth list of the capacity forecast
+const maxGrowthContributors = 10
+
+// Why a topic's steady-state size is what it is
+const (
+	BoundTime      = "time"      // retention.ms
+	BoundSize      = "size"      // retention.bytes per partition
+	BoundCompacted = "compacted" // cleanup.policy=compact, assumed stable
+	BoundUnbounded = "unbounded" // no retention and still growing
+	BoundStable    = "stable"    // not growing
+)
+
+// CapacityForecast projects topic and broker disk usage from topic sizes history
+type CapacityForecast struct {
+	Timestamp    string              `json:"timestamp"`
+	HistoryFrom  string              `json:"history_from"`
+	HistoryTo    string              `json:"history_to"`
+	Snapshots    int                 `json:"snapshots"`
+	DiskCapacity int64               `json:"disk_capacity_bytes,omitempty"`
+	Brokers      []BrokerForecast    `json:"brokers"`
+	TopGrowth    []GrowthContributor `json:"top_growth"`
+	Topics       []TopicForecast     `json:"topics"`
+}
+
+// TopicForecast is the disk usage forecast of one topic, replicas included
+type TopicForecast struct {
+	Topic           string  `json:"topic"`
+	CurrentSize     int64   `json:"current_size_bytes"`
+	GrowthPerDay    float64 `json:"growth_bytes_per_day"`
+	IngestPerDay    float64 `json:"ingest_bytes_per_day"`
+	IngestSource    string  `json:"ingest_source"` // "produce-rate", "sample" or "growth"
+	RetentionMs     int64   `json:"retention_ms"`
+	RetentionBytes  int64   `json:"retention_bytes"`
+	CleanupPolicy   string  `json:"cleanup_policy"`
+	SteadyStateSize int64   `json:"steady_state_bytes"` // -1 when unbounded
+	BoundBy         string  `json:"bound_by"`
+}
+
+// BrokerForecast projects when a broker's disk fills
+type BrokerForecast struct {
+	Broker          int32            `json:"broker"`
+	CurrentSize     int64            `json:"current_size_bytes"`
+	LogDirs         map[string]int64 `json:"log_dirs,omitempty"`
+	GrowthPerDay    float64          `json:"growth_bytes_per_day"`
+	SteadyStateSize int64            `json:"steady_state_bytes"` // -1 when unbounded
+	UsedPercent     float64          `json:"used_percent,omitempty"`
+	Status          string           `json:"status,omitempty"` // full, fills, fits, not-growing
+	DaysUntilFull   *float64         `json:"days_until_full,omitempty"`
+	FullAt          string           `json:"full_at,omitempty"`
+}
+
+// GrowthContributor is a topic's share of the cluster's growth
+type GrowthContributor struct {
+	Topic        string  `json:"topic"`
+	GrowthPerDay float64 `json:"growth_bytes_per_day"`
+	Share        float64 `json:"share"`
+}
+
+// retentionDefaults are the broker-level defaults for topics without overrides
+type retentionDefaults struct {
+	RetentionMs    int64
+	RetentionBytes int64
+	CleanupPolicy  string
+}
+
+// kafkaRetentionDefaults are Kafka's own defaults: 7 days, unlimited size, delete
+var kafkaRetentionDefaults = retentionDefaults{
+	RetentionMs:    7 * 24 * time.Hour.Milliseconds(),
+	RetentionBytes: -1,
+	CleanupPolicy:  "delete",
+}
+
+// sizePoint is a size at a point in time
+type sizePoint struct {
+	at   time.Time
+	size int64
+}
+
+// parseByteSize parses sizes like 500GiB, 2TB, 1.5T or 1073741824. KB/MB/GB/TB/PB are decimal,
+// KiB/MiB/GiB/TiB/PiB and the short forms K/M/G/T/P are binary.
+func parseByteSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	multipliers := map[string]float64{
+		"": 1, "B": 1,
+		"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12, "PB": 1e15,
+		"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40, "P": 1 << 50,
+		"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30, "TIB": 1 << 40, "PIB": 1 << 50,
+	}
+	multiplier, ok := multipliers[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", value, unit)
+	}
+	return int64(n * multiplier), nil
+}
+
+// loadSizesHistory reads topic sizes reports from comma-separated files or globs, oldest first
+func loadSizesHistory(value string) ([]*TopicSizesReport, error) {
+	var files []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no topic sizes report matches %s", pattern)
+		}
+		files = append(files, matches...)
+	}
+
+	var reports []*TopicSizesReport
+	for _, file := range files {
+		report, err := loadTopicSizesReport(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := time.Parse(time.RFC3339, report.Timestamp); err != nil {
+			return nil, fmt.Errorf("topic sizes report %s has no valid timestamp: %v", file, err)
+		}
+		reports = append(reports, report)
+	}
+	if len(reports) < 2 {
+		return nil, fmt.Errorf("at least two topic sizes reports are needed, got %d", len(reports))
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		a, _ := time.Parse(time.RFC3339, reports[i].Timestamp)
+		b, _ := time.Parse(time.RFC3339, reports[j].Timestamp)
+		return a.Before(b)
+	})
+	return reports, nil
+}
+
+// fetchRetentionDefaults reads the broker's log.retention.* and log.cleanup.policy settings,
+// falling back to Kafka's defaults
+func fetchRetentionDefaults(admin sarama.ClusterAdmin, brokerID int32) retentionDefaults {
+	defaults := kafkaRetentionDefaults
+
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{
+		Type: sarama.BrokerResource,
+		Name: strconv.Itoa(int(brokerID)),
+	})
+	if err != nil {
+		log.Printf("Warning: Could not read broker retention defaults, assuming 7 days: %v", err)
+		return defaults
+	}
+
+	configs := make(map[string]string)
+	for _, entry := range entries {
+		configs[entry.Name] = entry.Value
+	}
+	// log.retention.ms takes precedence over minutes, minutes over hours
+	if v, err := strconv.ParseInt(configs["log.retention.ms"], 10, 64); err == nil {
+		defaults.RetentionMs = v
+	} else if v, err := strconv.ParseInt(configs["log.retention.minutes"], 10, 64); err == nil {
+		defaults.RetentionMs = v * time.Minute.Milliseconds()
+	} else if v, err := strconv.ParseInt(configs["log.retention.hours"], 10, 64); err == nil {
+		defaults.RetentionMs = v * time.Hour.Milliseconds()
+	}
+	if v, err := strconv.ParseInt(configs["log.retention.bytes"], 10, 64); err == nil {
+		defaults.RetentionBytes = v
+	}
+	if v := configs["log.cleanup.policy"]; v != "" {
+		defaults.CleanupPolicy = v
+	}
+	return defaults
+}
+
+// topicRetention applies a topic's retention.ms, retention.bytes and cleanup.policy overrides to the broker defaults
+func topicRetention(configs map[string]string, defaults retentionDefaults) retentionDefaults {
+	retention := defaults
+	if v, err := strconv.ParseInt(configs["retention.ms"], 10, 64); err == nil {
+		retention.RetentionMs = v
+	}
+	if v, err := strconv.ParseInt(configs["retention.bytes"], 10, 64); err == nil {
+		retention.RetentionBytes = v
+	}
+	if v := configs["cleanup.policy"]; v != "" {
+		retention.CleanupPolicy = v
+	}
+	return retention
+}
+
+// growthPerDay is the least-squares slope of the sizes, in bytes per day
+func growthPerDay(points []sizePoint) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+	t0 := points[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.at.Sub(t0).Hours() / 24
+		y := float64(p.size)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// buildCapacityForecast combines the sizes history with the topics' retention settings and
+// produce rates. capacity is the usable disk per broker, 0 if unknown.
+func buildCapacityForecast(info *KafkaClusterInfo, history []*TopicSizesReport, defaults retentionDefaults, capacity int64, now time.Time) *CapacityForecast {
+	latest := history[len(history)-1]
+	forecast := &CapacityForecast{
+		Timestamp:    now.Format(time.RFC3339),
+		HistoryFrom:  history[0].Timestamp,
+		HistoryTo:    latest.Timestamp,
+		Snapshots:    len(history),
+		DiskCapacity: capacity,
+	}
+
+	topicSizes := make(map[string][]sizePoint)
+	brokerSizes := make(map[int32][]sizePoint)
+	for _, report := range history {
+		at, _ := time.Parse(time.RFC3339, report.Timestamp)
+		for _, topic := range report.Topics {
+			topicSizes[topic.Topic] = append(topicSizes[topic.Topic], sizePoint{at, topic.TotalSize})
+		}
+		for _, broker := range report.Brokers {
+			brokerSizes[broker.Broker] = append(brokerSizes[broker.Broker], sizePoint{at, broker.TotalSize})
+		}
+	}
+
+	// Topics: steady state under current retention
+	steadyStates := make(map[string]int64)
+	var totalGrowth float64
+	for _, topic := range info.Topics {
+		points := topicSizes[topic.Name]
+		if len(points) == 0 {
+			continue
+		}
+		f := topicForecast(topic, points, defaults)
+		steadyStates[topic.Name] = f.SteadyStateSize
+		if f.GrowthPerDay > 0 {
+			totalGrowth += f.GrowthPerDay
+		}
+		forecast.Topics = append(forecast.Topics, f)
+	}
+	sort.SliceStable(forecast.Topics, func(i, j int) bool {
+		return forecast.Topics[i].GrowthPerDay > forecast.Topics[j].GrowthPerDay
+	})
+	for _, f := range forecast.Topics {
+		if f.GrowthPerDay <= 0 || len(forecast.TopGrowth) == maxGrowthContributors {
+			break
+		}
+		forecast.TopGrowth = append(forecast.TopGrowth, GrowthContributor{
+			Topic:        f.Topic,
+			GrowthPerDay: f.GrowthPerDay,
+			Share:        f.GrowthPerDay / totalGrowth,
+		})
+	}
+
+	// Brokers: each topic's steady state split by the broker's current share of the topic
+	topicTotals := make(map[string]int64)
+	for _, topic := range latest.Topics {
+		topicTotals[topic.Topic] = topic.TotalSize
+	}
+	for _, broker := range latest.Brokers {
+		b := BrokerForecast{
+			Broker:       broker.Broker,
+			CurrentSize:  broker.TotalSize,
+			LogDirs:      broker.LogDirs,
+			GrowthPerDay: growthPerDay(brokerSizes[broker.Broker]),
+		}
+		var steady float64
+		for topic, size := range broker.Topics {
+			topicSteady, ok := steadyStates[topic]
+			if !ok || topicTotals[topic] == 0 {
+				steady += float64(size) // topic not in the cluster info, assume it stays as is
+				continue
+			}
+			if topicSteady < 0 {
+				steady = -1
+				break
+			}
+			steady += float64(topicSteady) * float64(size) / float64(topicTotals[topic])
+		}
+		b.SteadyStateSize = int64(steady)
+
+		if capacity > 0 {
+			b.UsedPercent = float64(b.CurrentSize) * 100 / float64(capacity)
+			switch {
+			case b.CurrentSize >= capacity:
+				b.Status = "full"
+			case b.SteadyStateSize >= 0 && b.SteadyStateSize < capacity:
+				b.Status = "fits"
+			case b.GrowthPerDay > 0:
+				days := float64(capacity-b.CurrentSize) / b.GrowthPerDay
+				b.Status = "fills"
+				b.DaysUntilFull = &days
+				b.FullAt = now.Add(time.Duration(days * 24 * float64(time.Hour))).Format("2006-01-02")
+			default:
+				b.Status = "not-growing"
+			}
+		}
+		forecast.Brokers = append(forecast.Brokers, b)
+	}
+
+	return forecast
+}
+
+// topicForecast estimates a topic's ingest and steady-state size from its size history
+func topicForecast(topic TopicInfo, points []sizePoint, defaults retentionDefaults) TopicForecast {
+	retention := topicRetention(topic.Configs, defaults)
+	f := TopicForecast{
+		Topic:          topic.Name,
+		CurrentSize:    points[len(points)-1].size,
+		GrowthPerDay:   growthPerDay(points),
+		RetentionMs:    retention.RetentionMs,
+		RetentionBytes: retention.RetentionBytes,
+		CleanupPolicy:  retention.CleanupPolicy,
+	}
+
+	// Ingest on disk (all replicas): measured produce rate if available, otherwise the growth,
+	// which equals the ingest until retention starts deleting and is 0 at steady state
+	replicas := float64(topic.ReplicationFactor)
+	if rate := topic.ProduceRate; rate != nil && rate.BytesPerSec > 0 && replicas > 0 {
+		f.IngestPerDay = rate.BytesPerSec * replicas * 86400
+		f.IngestSource = "produce-rate"
+		if rate.BytesSource == "sample" {
+			f.IngestSource = "sample"
+		}
+	} else {
+		f.IngestPerDay = f.GrowthPerDay
+		if f.IngestPerDay < 0 {
+			f.IngestPerDay = 0
+		}
+		f.IngestSource = "growth"
+	}
+
+	deletes := strings.Contains(f.CleanupPolicy, "delete")
+	switch {
+	case !deletes:
+		f.SteadyStateSize = f.CurrentSize
+		f.BoundBy = BoundCompacted
+	case f.IngestPerDay == 0:
+		f.SteadyStateSize = f.CurrentSize
+		f.BoundBy = BoundStable
+	case f.RetentionMs < 0 && f.RetentionBytes < 0:
+		f.SteadyStateSize = -1
+		f.BoundBy = BoundUnbounded
+	default:
+		steady := int64(-1)
+		if f.RetentionMs >= 0 {
+			steady = int64(f.IngestPerDay * float64(f.RetentionMs) / float64(24*time.Hour.Milliseconds()))
+			// Growth underestimates the ingest once retention deletes; a growing topic keeps at least its size
+			if f.IngestSource == "growth" && steady < f.CurrentSize {
+				steady = f.CurrentSize
+			}
+			f.BoundBy = BoundTime
+		}
+		// retention.bytes applies to each partition replica
+		if f.RetentionBytes >= 0 {
+			limit := f.RetentionBytes * int64(topic.Partitions) * int64(topic.ReplicationFactor)
+			if steady < 0 || limit < steady {
+				steady = limit
+				f.BoundBy = BoundSize
+			}
+		}
+		f.SteadyStateSize = steady
+	}
+	return f
+}
+
+// printCapacityForecast prints the broker projection and the top growth contributors
+func printCapacityForecast(f *CapacityForecast) {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Kafka Capacity Forecast\n")
+	fmt.Printf("History: %s to %s (%d snapshots)\n", f.HistoryFrom, f.HistoryTo, f.Snapshots)
+	if f.DiskCapacity > 0 {
+		fmt.Printf("Disk capacity per broker: %s\n", formatBytes(f.DiskCapacity))
+	}
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "BROKER\tUSED\tGROWTH/DAY\tSTEADY STATE\tSTATUS\tFULL AT")
+	for _, b := range f.Brokers {
+		used := formatBytes(b.CurrentSize)
+		if b.UsedPercent > 0 {
+			used += fmt.Sprintf(" (%.0f%%)", b.UsedPercent)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", b.Broker, used, formatSignedBytes(b.GrowthPerDay),
+			formatSteadyState(b.SteadyStateSize), b.Status, b.FullAt)
+	}
+	w.Flush()
+
+	if len(f.TopGrowth) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "TOP GROWTH\tGROWTH/DAY\tSHARE")
+		for _, g := range f.TopGrowth {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", g.Topic, formatSignedBytes(g.GrowthPerDay), formatPercent(g.Share))
+		}
+		w.Flush()
+	}
+	fmt.Println()
+}
+
+func formatSignedBytes(n float64) string {
+	if n < 0 {
+		return "-" + formatBytes(int64(-n))
+	}
+	return "+" + formatBytes(int64(n))
+}
+
+func formatSteadyState(n int64) string {
+	if n < 0 {
+		return "unbounded"
+	}
+	return formatBytes(n)
+}
+
+// saveCapacityForecast writes the forecast as JSON
+func saveCapacityForecast(f *CapacityForecast, filename string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling forecast: %v", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"1073741824", 1073741824},
+		{"0", 0},
+		{"5B", 5},
+		{"10 kb", 10000},
+		{"2TB", 2000000000000},
+		{"500GB", 500000000000},
+		{"1K", 1024},
+		{"500GiB", 500 << 30},
+		{"500G", 500 << 30},
+		{"1.5T", 3 << 39},
+		{"2PiB", 2 << 50},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.value)
+		if err != nil {
+			t.Errorf("parseByteSize(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "GB", "-1GB", "12XB", "1.2.3G"} {
+		if _, err := parseByteSize(value); err == nil {
+			t.Errorf("parseByteSize(%q): expected an error", value)
+		}
+	}
+}
+
+func TestGrowthPerDay(t *testing.T) {
+	day0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days float64) time.Time {
+		return day0.Add(time.Duration(days * 24 * float64(time.Hour)))
+	}
+	tests := []struct {
+		name   string
+		points []sizePoint
+		want   float64
+	}{
+		{"linear", []sizePoint{{at(0), 100}, {at(1), 200}, {at(2), 300}}, 100},
+		{"least squares", []sizePoint{{at(0), 0}, {at(1), 150}, {at(2), 200}}, 100},
+		{"half days", []sizePoint{{at(0), 1000}, {at(0.5), 1500}}, 1000},
+		{"shrinking", []sizePoint{{at(0), 300}, {at(3), 0}}, -100},
+		{"single point", []sizePoint{{at(0), 100}}, 0},
+		{"same time", []sizePoint{{at(1), 100}, {at(1), 200}}, 0},
+	}
+	for _, tt := range tests {
+		if got := growthPerDay(tt.points); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%s: growthPerDay = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTopicForecast(t *testing.T) {
+	day0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	growing := []sizePoint{{day0, 1000}, {day0.Add(24 * time.Hour), 2000}} // +1000 bytes/day
+	flat := []sizePoint{{day0, 1000}, {day0.Add(24 * time.Hour), 1000}}
+	slow := []sizePoint{{day0, 1000}, {day0.Add(24 * time.Hour), 1100}} // +100 bytes/day
+	week := "604800000"
+	day := "86400000"
+
+	tests := []struct {
+		name    string
+		topic   TopicInfo
+		points  []sizePoint
+		steady  int64
+		boundBy string
+		ingest  float64
+		source  string
+	}{
+		{
+			name:    "time bound",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": week}},
+			points:  growing,
+			steady:  7000,
+			boundBy: BoundTime,
+			ingest:  1000,
+			source:  "growth",
+		},
+		{
+			name:    "size bound below time bound",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": week, "retention.bytes": "500"}},
+			points:  growing,
+			steady:  500 * 3 * 2,
+			boundBy: BoundSize,
+			ingest:  1000,
+			source:  "growth",
+		},
+		{
+			name:    "time bound below size bound",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": week, "retention.bytes": "10000"}},
+			points:  growing,
+			steady:  7000,
+			boundBy: BoundTime,
+			ingest:  1000,
+			source:  "growth",
+		},
+		{
+			name:    "size bound only",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": "-1", "retention.bytes": "500"}},
+			points:  growing,
+			steady:  3000,
+			boundBy: BoundSize,
+			ingest:  1000,
+			source:  "growth",
+		},
+		{
+			name:    "unbounded",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": "-1"}},
+			points:  growing,
+			steady:  -1,
+			boundBy: BoundUnbounded,
+			ingest:  1000,
+			source:  "growth",
+		},
+		{
+			name:    "compacted",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"cleanup.policy": "compact", "retention.ms": "-1"}},
+			points:  growing,
+			steady:  2000,
+			boundBy: BoundCompacted,
+			ingest:  1000,
+			source:  "growth",
+		},
+		{
+			name:    "stable",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": "-1"}},
+			points:  flat,
+			steady:  1000,
+			boundBy: BoundStable,
+			source:  "growth",
+		},
+		{
+			name:    "growth below current size",
+			topic:   TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"retention.ms": day}},
+			points:  slow,
+			steady:  1100,
+			boundBy: BoundTime,
+			ingest:  100,
+			source:  "growth",
+		},
+		{
+			name: "produce rate",
+			topic: TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 3, Configs: map[string]string{"retention.ms": day},
+				ProduceRate: &ProduceRate{BytesPerSec: 10, BytesSource: "topic-sizes"}},
+			points:  flat,
+			steady:  10 * 3 * 86400,
+			boundBy: BoundTime,
+			ingest:  10 * 3 * 86400,
+			source:  "produce-rate",
+		},
+		{
+			name: "sampled produce rate",
+			topic: TopicInfo{Name: "t", Partitions: 3, ReplicationFactor: 1, Configs: map[string]string{"retention.ms": day},
+				ProduceRate: &ProduceRate{BytesPerSec: 1, BytesSource: "sample"}},
+			points:  flat,
+			steady:  86400,
+			boundBy: BoundTime,
+			ingest:  86400,
+			source:  "sample",
+		},
+	}
+	for _, tt := range tests {
+		f := topicForecast(tt.topic, tt.points, kafkaRetentionDefaults)
+		if f.SteadyStateSize != tt.steady || f.BoundBy != tt.boundBy {
+			t.Errorf("%s: steady state %d bound by %s, want %d bound by %s", tt.name, f.SteadyStateSize, f.BoundBy, tt.steady, tt.boundBy)
+		}
+		if math.Abs(f.IngestPerDay-tt.ingest) > 1e-6 || f.IngestSource != tt.source {
+			t.Errorf("%s: ingest %v from %s, want %v from %s", tt.name, f.IngestPerDay, f.IngestSource, tt.ingest, tt.source)
+		}
+	}
+}
+
+func TestBuildCapacityForecast(t *testing.T) {
+	report := func(timestamp string, events, logs int64, brokers ...BrokerSize) *TopicSizesReport {
+		return &TopicSizesReport{
+			Timestamp: timestamp,
+			Topics: []TopicSize{
+				{Topic: "events", TotalSize: events},
+				{Topic: "logs", TotalSize: logs},
+				{Topic: "legacy", TotalSize: 12000},
+			},
+			Brokers: brokers,
+		}
+	}
+	history := []*TopicSizesReport{
+		report("2024-01-01T00:00:00Z", 1000, 1000,
+			BrokerSize{Broker: 1, TotalSize: 1000},
+			BrokerSize{Broker: 2, TotalSize: 1000},
+			BrokerSize{Broker: 3, TotalSize: 12000},
+			BrokerSize{Broker: 4, TotalSize: 500}),
+		report("2024-01-02T00:00:00Z", 2000, 3000,
+			BrokerSize{Broker: 1, TotalSize: 2000, Topics: map[string]int64{"events": 2000}},
+			BrokerSize{Broker: 2, TotalSize: 3000, Topics: map[string]int64{"logs": 3000}},
+			BrokerSize{Broker: 3, TotalSize: 12000, Topics: map[string]int64{"legacy": 12000}},
+			BrokerSize{Broker: 4, TotalSize: 500, Topics: map[string]int64{"logs": 500}}),
+	}
+	info := &KafkaClusterInfo{Topics: []TopicInfo{
+		{Name: "events", Partitions: 1, ReplicationFactor: 1, Configs: map[string]string{"retention.ms": "172800000"}},
+		{Name: "logs", Partitions: 1, ReplicationFactor: 1, Configs: map[string]string{"retention.ms": "-1"}},
+	}}
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	f := buildCapacityForecast(info, history, kafkaRetentionDefaults, 10000, now)
+
+	if f.Snapshots != 2 || f.HistoryFrom != "2024-01-01T00:00:00Z" || f.Timestamp != "2024-01-10T00:00:00Z" {
+		t.Errorf("unexpected header: %+v", f)
+	}
+	if len(f.Topics) != 2 || f.Topics[0].Topic != "logs" || f.Topics[1].Topic != "events" {
+		t.Fatalf("expected logs and events by growth, got %+v", f.Topics)
+	}
+	if len(f.TopGrowth) != 2 || math.Abs(f.TopGrowth[0].Share-2.0/3) > 1e-9 || math.Abs(f.TopGrowth[1].Share-1.0/3) > 1e-9 {
+		t.Errorf("unexpected growth shares: %+v", f.TopGrowth)
+	}
+
+	want := []struct {
+		steady int64
+		status string
+		days   float64
+		fullAt string
+	}{
+		{2000, "fits", 0, ""},            // events stays at 2 days of 1000 bytes/day
+		{-1, "fills", 3.5, "2024-01-13"}, // 7000 bytes left at 2000 bytes/day
+		{12000, "full", 0, ""},           // over capacity already
+		{-1, "not-growing", 0, ""},       // unbounded topic, but the broker does not grow
+	}
+	if len(f.Brokers) != len(want) {
+		t.Fatalf("expected %d brokers, got %d", len(want), len(f.Brokers))
+	}
+	for i, w := range want {
+		b := f.Brokers[i]
+		if b.SteadyStateSize != w.steady || b.Status != w.status || b.FullAt != w.fullAt {
+			t.Errorf("broker %d: steady state %d, status %s, full at %q; want %d, %s, %q",
+				b.Broker, b.SteadyStateSize, b.Status, b.FullAt, w.steady, w.status, w.fullAt)
+		}
+		if (b.DaysUntilFull != nil) != (w.days > 0) || (b.DaysUntilFull != nil && math.Abs(*b.DaysUntilFull-w.days) > 1e-9) {
+			t.Errorf("broker %d: days until full %v, want %v", b.Broker, b.DaysUntilFull, w.days)
+		}
+	}
+	if f.Brokers[0].UsedPercent != 20 {
+		t.Errorf("broker 1: used %v%%, want 20%%", f.Brokers[0].UsedPercent)
+	}
+}
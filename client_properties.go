@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PBES2 parameters for the encrypted keystore key, as openssl pkcs8 -topk8 -v2 aes-256-cbc writes it
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// pbkdf2Iterations is the PBKDF2 iteration count for the keystore key
+const pbkdf2Iterations = 100000
+
+// scriptSafePath matches paths that can be used unquoted in generated scripts
+var scriptSafePath = regexp.MustCompile(`^[A-Za-z0-9._/@%+=:,~-]+$`)
+
+// kafkaClientProperties returns the Kafka CLI client properties equivalent to the sarama config.
+// keystorePath is where the PEM keystore for mTLS will be written and keyPassword encrypts its key
+// (see writeKafkaClientProperties).
+func kafkaClientProperties(config *sarama.Config, opts SecurityOptions, keystorePath, keyPassword string) ([]string, error) {
+	var lines []string
+
+	// Add SASL configuration
+	if config.Net.SASL.Enable {
+		protocol := "SASL_PLAINTEXT"
+		if config.Net.TLS.Enable {
+			protocol = "SASL_SSL"
+		}
+		lines = append(lines, fmt.Sprintf("security.protocol=%s", protocol))
+		if config.Net.SASL.Mechanism != sarama.SASLTypeOAuth {
+			lines = append(lines, fmt.Sprintf("sasl.mechanism=%s", config.Net.SASL.Mechanism))
+		}
+
+		switch config.Net.SASL.Mechanism {
+		case "PLAIN":
+			lines = append(lines, fmt.Sprintf("sasl.jaas.config=org.apache.kafka.common.security.plain.PlainLoginModule required username=\"%s\" password=\"%s\";",
+				config.Net.SASL.User, config.Net.SASL.Password))
+		case "SCRAM-SHA-256", "SCRAM-SHA-512":
+			lines = append(lines, fmt.Sprintf("sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required username=\"%s\" password=\"%s\";",
+				config.Net.SASL.User, config.Net.SASL.Password))
+		case sarama.SASLTypeGSSAPI:
+			gssapi := config.Net.SASL.GSSAPI
+			lines = append(lines, fmt.Sprintf("sasl.kerberos.service.name=%s", gssapi.ServiceName))
+			lines = append(lines, "sasl.jaas.config="+kerberosJAASConfig(gssapi))
+			if gssapi.AuthType == sarama.KRB5_USER_AUTH {
+				lines = append(lines, "# Password authentication: run kinit before using this file")
+			}
+			lines = append(lines, fmt.Sprintf("# Requires KAFKA_OPTS=\"%s\"", kerberosJavaOpts(gssapi)))
+		case sarama.SASLTypeOAuth:
+			lines = append(lines, mskIAMJAASLines(config)...)
+		}
+	} else if config.Net.TLS.Enable {
+		lines = append(lines, "security.protocol=SSL")
+	}
+
+	if !config.Net.TLS.Enable || config.Net.TLS.Config == nil {
+		return lines, nil
+	}
+
+	// Trust store: the CA file is already PEM, so the CLI can read it directly (Kafka 2.7+).
+	// Without -tls-ca-cert the JVM default trust store is used, like the system pool in kmap.
+	if opts.TLSCACert != "" {
+		caPath, err := filepath.Abs(opts.TLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, "ssl.truststore.type=PEM")
+		lines = append(lines, fmt.Sprintf("ssl.truststore.location=%s", caPath))
+	}
+
+	// Key store: the JVM needs the key as encrypted PKCS#8 in the same file as the certificate
+	// chain; file-based PEM keystores require ssl.key.password
+	if len(config.Net.TLS.Config.Certificates) > 0 {
+		lines = append(lines, "ssl.keystore.type=PEM")
+		lines = append(lines, fmt.Sprintf("ssl.keystore.location=%s", keystorePath))
+		lines = append(lines, fmt.Sprintf("ssl.key.password=%s", keyPassword))
+	}
+
+	if opts.TLSSkipVerify {
+		// The JVM has no switch to skip chain validation; this only disables hostname checks
+		lines = append(lines, "# -tls-skip-verify: hostname verification disabled, the broker certificate must still be trusted")
+		lines = append(lines, "ssl.endpoint.identification.algorithm=")
+	}
+
+	return lines, nil
+}
+
+// writeKafkaClientProperties writes a properties file for the Kafka CLI tools (--command-config).
+// For mTLS, a PEM keystore is written next to it as <filename>.keystore.pem.
+// It returns all files written, so callers can remove them afterwards.
+func writeKafkaClientProperties(config *sarama.Config, opts SecurityOptions, filename string) ([]string, error) {
+	absName, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	keystorePath := absName + ".keystore.pem"
+
+	// A random password only protects the key at rest; it is stored next to it in the properties file
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	keyPassword := hex.EncodeToString(secret)
+
+	lines, err := kafkaClientProperties(config, opts, keystorePath, keyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	if config.Net.TLS.Enable && config.Net.TLS.Config != nil && len(config.Net.TLS.Config.Certificates) > 0 {
+		if err := writePEMKeystore(config, keystorePath, keyPassword); err != nil {
+			return nil, fmt.Errorf("failed to write PEM keystore: %v", err)
+		}
+		written = append(written, keystorePath)
+	}
+
+	// Credentials end up in the file, keep it private
+	if err := os.WriteFile(absName, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		for _, f := range written {
+			os.Remove(f)
+		}
+		return nil, err
+	}
+	written = append(written, absName)
+
+	return written, nil
+}
+
+// writePEMKeystore converts the loaded client certificate and key into a PEM keystore, with the
+// key encrypted by password
+func writePEMKeystore(config *sarama.Config, filename, password string) error {
+	cert := config.Net.TLS.Config.Certificates[0]
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("unsupported client key: %v", err)
+	}
+	encrypted, err := encryptPKCS8(key, password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt client key: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := pem.Encode(&buf, &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted}); err != nil {
+		return err
+	}
+	for _, der := range cert.Certificate {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(filename, []byte(buf.String()), 0600)
+}
+
+// encryptPKCS8 wraps a PKCS#8 key in an EncryptedPrivateKeyInfo with PBES2
+// (PBKDF2-HMAC-SHA256, AES-256-CBC)
+func encryptPKCS8(key []byte, password string) ([]byte, error) {
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(password), salt, pbkdf2Iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(key)%aes.BlockSize
+	data := append(append([]byte(nil), key...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	type pbkdf2Params struct {
+		Salt       []byte
+		Iterations int
+		KeyLength  int
+		PRF        pkix.AlgorithmIdentifier
+	}
+	type pbes2Params struct {
+		KeyDerivation pkix.AlgorithmIdentifier
+		Encryption    pkix.AlgorithmIdentifier
+	}
+
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: pbkdf2Iterations,
+		KeyLength:  32,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivation: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		Encryption:    pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		Data      []byte
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		Data:      data,
+	})
+}
+
+// checkScriptPath rejects paths whose absolute form would break when expanded unquoted in a
+// generated script, e.g. $COMMAND_CONFIG
+func checkScriptPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !scriptSafePath.MatchString(abs) {
+		return fmt.Errorf("path %q contains spaces or shell special characters; use a path without them", abs)
+	}
+	return nil
+}
+
+// commandConfigLines returns the COMMAND_CONFIG block of a generated script.
+// With a properties file from -command-config it is used directly, otherwise it is left for the user to fill in.
+func commandConfigLines(commandConfig string) string {
+	if commandConfig == "" {
+		return "# Uncomment and configure if authentication is needed:\n" +
+			"# COMMAND_CONFIG=\"--command-config client.properties\"\n" +
+			"COMMAND_CONFIG=\"\"\n"
+	}
+
+	path, err := filepath.Abs(commandConfig)
+	if err != nil {
+		path = commandConfig
+	}
+	return "# Client properties generated by kmap from its connection flags (-command-config).\n" +
+		"# Edit the file if the target cluster uses different credentials.\n" +
+		fmt.Sprintf("COMMAND_CONFIG=\"--command-config %s\"\n", path)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// testClientCertificate returns a self-signed client certificate
+func testClientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kmap-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// decryptPKCS8 reverses encryptPKCS8
+func decryptPKCS8(t *testing.T, der []byte, password string) []byte {
+	t.Helper()
+	var info struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters struct {
+				KeyDerivation struct {
+					Algorithm  asn1.ObjectIdentifier
+					Parameters struct {
+						Salt       []byte
+						Iterations int
+						KeyLength  int
+						PRF        pkix.AlgorithmIdentifier
+					}
+				}
+				Encryption struct {
+					Algorithm asn1.ObjectIdentifier
+					IV        []byte
+				}
+			}
+		}
+		Data []byte
+	}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatalf("parse EncryptedPrivateKeyInfo: %v", err)
+	}
+	params := info.Algorithm.Parameters
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) || !params.KeyDerivation.Algorithm.Equal(oidPBKDF2) ||
+		!params.Encryption.Algorithm.Equal(oidAES256CBC) || !params.KeyDerivation.Parameters.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		t.Fatalf("unexpected algorithms: %+v", info.Algorithm)
+	}
+
+	kdf := params.KeyDerivation.Parameters
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(password), kdf.Salt, kdf.Iterations, kdf.KeyLength, sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte(nil), info.Data...)
+	cipher.NewCBCDecrypter(block, params.Encryption.IV).CryptBlocks(data, data)
+	padding := int(data[len(data)-1])
+	return data[:len(data)-padding]
+}
+
+func TestWriteKafkaClientPropertiesMTLS(t *testing.T) {
+	config := sarama.NewConfig()
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = &tls.Config{Certificates: []tls.Certificate{testClientCertificate(t)}}
+
+	filename := filepath.Join(t.TempDir(), "client.properties")
+	files, err := writeKafkaClientProperties(config, SecurityOptions{}, filename)
+	if err != nil {
+		t.Fatalf("writeKafkaClientProperties: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected keystore and properties, got %v", files)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok {
+			props[k] = v
+		}
+	}
+	if props["ssl.keystore.type"] != "PEM" || props["ssl.keystore.location"] != files[0] {
+		t.Errorf("unexpected keystore properties: %v", props)
+	}
+	password := props["ssl.key.password"]
+	if password == "" {
+		t.Fatal("ssl.key.password is missing")
+	}
+
+	keystore, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, rest := pem.Decode(keystore)
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		t.Fatalf("expected an encrypted private key first, got %v", block)
+	}
+	if cert, _ := pem.Decode(rest); cert == nil || cert.Type != "CERTIFICATE" {
+		t.Fatal("expected the certificate after the key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(decryptPKCS8(t, block.Bytes, password))
+	if err != nil {
+		t.Fatalf("decrypted key does not parse: %v", err)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); !ok {
+		t.Errorf("unexpected key type %T", key)
+	}
+
+	// Cross-check with OpenSSL, which reads the same format as the JVM
+	if openssl, err := exec.LookPath("openssl"); err == nil {
+		out, err := exec.Command(openssl, "pkcs8", "-in", files[0], "-passin", "pass:"+password).CombinedOutput()
+		if err != nil {
+			t.Errorf("openssl could not decrypt the key: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestCheckScriptPath(t *testing.T) {
+	for _, path := range []string{"client.properties", "/etc/kafka/client-prod.properties"} {
+		if err := checkScriptPath(path); err != nil {
+			t.Errorf("checkScriptPath(%q): %v", path, err)
+		}
+	}
+	for _, path := range []string{"/tmp/my config/client.properties", "/tmp/$(id).properties", "/tmp/a'b"} {
+		if err := checkScriptPath(path); err == nil {
+			t.Errorf("checkScriptPath(%q): expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// commitHistoryFetchBytes is the fetch size for reading __consumer_offsets
+const commitHistoryFetchBytes = 1024 * 1024
+
+// commitHistoryPrintLimit is the number of stale groups printed to the console
+const commitHistoryPrintLimit = 50
+
+// CommitHistoryReport is the commit history of every group, decoded from __consumer_offsets
+type CommitHistoryReport struct {
+	Timestamp         string               `json:"timestamp"`
+	Cluster           string               `json:"cluster"`
+	PartitionsRead    int                  `json:"partitions_read"`
+	RecordsRead       int64                `json:"records_read"`
+	UndecodedRecords  int64                `json:"undecoded_records,omitempty"` // newer key versions or corrupt records
+	Truncated         bool                 `json:"truncated,omitempty"`         // older records skipped by -commit-history-records
+	StaleAfterSeconds float64              `json:"stale_after_seconds"`
+	Groups            []GroupCommitHistory `json:"consumer_groups"`
+}
+
+// GroupCommitHistory is the last commit and the latest metadata of a group
+type GroupCommitHistory struct {
+	Group                    string                `json:"group"`
+	LastCommit               string                `json:"last_commit,omitempty"`
+	SinceLastCommitSeconds   float64               `json:"since_last_commit_seconds,omitempty"`
+	FirstCommit              string                `json:"first_commit,omitempty"` // oldest commit still in the topic
+	Commits                  int                   `json:"commits"`                // commit requests; partitions committed together count once
+	AvgCommitIntervalSeconds float64               `json:"avg_commit_interval_seconds,omitempty"`
+	Stale                    bool                  `json:"stale"`
+	Deleted                  bool                  `json:"deleted,omitempty"` // the latest group metadata is a tombstone
+	ProtocolType             string                `json:"protocol_type,omitempty"`
+	Protocol                 string                `json:"protocol,omitempty"` // partition assignor
+	Generation               int32                 `json:"generation,omitempty"`
+	Leader                   string                `json:"leader,omitempty"`
+	StateChanged             string                `json:"state_changed,omitempty"`
+	Members                  []CommitHistoryMember `json:"members"`
+	Partitions               []PartitionCommit     `json:"partitions"`
+}
+
+// CommitHistoryMember is a member in the latest group metadata
+type CommitHistoryMember struct {
+	MemberID           string   `json:"member_id"`
+	GroupInstanceID    string   `json:"group_instance_id,omitempty"`
+	ClientID           string   `json:"client_id"`
+	ClientHost         string   `json:"client_host"`
+	SessionTimeoutMs   int32    `json:"session_timeout_ms"`
+	RebalanceTimeoutMs int32    `json:"rebalance_timeout_ms,omitempty"`
+	Topics             []string `json:"topics,omitempty"` // assigned, or subscribed if nothing is assigned
+}
+
+// PartitionCommit is the last committed offset of a partition
+type PartitionCommit struct {
+	Topic      string `json:"topic"`
+	Partition  int32  `json:"partition"`
+	Offset     int64  `json:"offset"`
+	LastCommit string `json:"last_commit"`
+	Commits    int    `json:"commits"`
+	Metadata   string `json:"metadata,omitempty"`
+}
+
+// CommitHistoryOptions configure readCommitHistory
+type CommitHistoryOptions struct {
+	MaxRecords int64         // last records read per partition, 0 = all
+	StaleAfter time.Duration // groups without commits for this long are stale, 0 = off
+	Groups     *NameFilter
+	Topics     *NameFilter
+	Cluster    string
+}
+
+// groupHistory accumulates the records of one group
+type groupHistory struct {
+	report     GroupCommitHistory
+	partitions map[string]map[int32]*PartitionCommit
+	first      int64 // commit timestamps, ms
+	last       int64
+	lastSeen   int64 // commit timestamp of the group's previous record
+}
+
+// readCommitHistory reads __consumer_offsets with plain fetch requests and replays the offset
+// commit and group metadata records. Compaction keeps only the latest record per key in older
+// segments, so the commit counts cover the uncompacted part of the log.
+func readCommitHistory(client sarama.Client, opts CommitHistoryOptions) (*CommitHistoryReport, error) {
+	partitions, err := client.Partitions(consumerOffsetsTopic)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", consumerOffsetsTopic, err)
+	}
+
+	report := &CommitHistoryReport{
+		Timestamp:         time.Now().UTC().Format(time.RFC3339),
+		Cluster:           opts.Cluster,
+		StaleAfterSeconds: opts.StaleAfter.Seconds(),
+		Groups:            make([]GroupCommitHistory, 0),
+	}
+	groups := make(map[string]*groupHistory)
+	group := func(name string) *groupHistory {
+		g, ok := groups[name]
+		if !ok {
+			g = &groupHistory{
+				report:     GroupCommitHistory{Group: name},
+				partitions: make(map[string]map[int32]*PartitionCommit),
+			}
+			groups[name] = g
+		}
+		return g
+	}
+
+	for _, partition := range partitions {
+		low, err := client.GetOffset(consumerOffsetsTopic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", consumerOffsetsTopic, err)
+		}
+		high, err := client.GetOffset(consumerOffsetsTopic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", consumerOffsetsTopic, err)
+		}
+		if opts.MaxRecords > 0 && high-low > opts.MaxRecords {
+			low = high - opts.MaxRecords
+			report.Truncated = true
+		}
+
+		for offset := low; offset < high; {
+			fetched, err := fetchRecords(client, consumerOffsetsTopic, partition, offset, high, 10000, commitHistoryFetchBytes)
+			if err != nil {
+				log.Printf("Warning: Could not read %s partition %d: %v", consumerOffsetsTopic, partition, err)
+				break
+			}
+			for _, rec := range fetched.Records {
+				report.RecordsRead++
+				if !replayOffsetsRecord(rec, group, opts) {
+					report.UndecodedRecords++
+				}
+			}
+			// Nothing more below the last stable offset: a transactional offset commit is open
+			if fetched.Next == offset {
+				log.Printf("Warning: Stopped reading %s partition %d at offset %d of %d (open transaction)", consumerOffsetsTopic, partition, offset, high)
+				break
+			}
+			offset = fetched.Next
+		}
+		report.PartitionsRead++
+	}
+
+	now := time.Now()
+	for _, g := range groups {
+		report.Groups = append(report.Groups, g.finish(now, opts.StaleAfter))
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Group < report.Groups[j].Group
+	})
+	return report, nil
+}
+
+// replayOffsetsRecord applies one __consumer_offsets record to its group. Returns false if the
+// record could not be decoded.
+func replayOffsetsRecord(rec fetchedRecord, group func(string) *groupHistory, opts CommitHistoryOptions) bool {
+	if rec.Key == nil {
+		return false
+	}
+	key, err := decodeOffsetsKey(rec.Key)
+	if err != nil {
+		return false
+	}
+
+	switch key.Version {
+	case offsetCommitKeyV0, offsetCommitKeyV1:
+		if !opts.Groups.Match(key.Group) || !opts.Topics.Match(key.Topic) {
+			return true
+		}
+		g := group(key.Group)
+		// Tombstone: the offset expired or was deleted
+		if rec.Value == nil {
+			delete(g.partitions[key.Topic], key.Partition)
+			return true
+		}
+		value, err := decodeOffsetCommitValue(rec.Value)
+		if err != nil {
+			return false
+		}
+		g.commit(key.Topic, key.Partition, value)
+
+	case groupMetadataKeyV2:
+		if !opts.Groups.Match(key.Group) {
+			return true
+		}
+		g := group(key.Group)
+		if rec.Value == nil {
+			g.report.Deleted = true
+			g.report.Members = nil
+			g.partitions = make(map[string]map[int32]*PartitionCommit)
+			return true
+		}
+		value, err := decodeGroupMetadataValue(rec.Value)
+		if err != nil {
+			return false
+		}
+		g.metadata(value)
+
+	default:
+		return false
+	}
+	return true
+}
+
+// commit records an offset commit
+func (g *groupHistory) commit(topic string, partition int32, value *offsetCommitValue) {
+	// A commit after a group tombstone recreates the group
+	g.report.Deleted = false
+	ts := value.CommitTimestamp
+	if ts != g.lastSeen {
+		g.report.Commits++
+		g.lastSeen = ts
+	}
+	if g.first == 0 || ts < g.first {
+		g.first = ts
+	}
+	if ts > g.last {
+		g.last = ts
+	}
+
+	if g.partitions[topic] == nil {
+		g.partitions[topic] = make(map[int32]*PartitionCommit)
+	}
+	p := g.partitions[topic][partition]
+	if p == nil {
+		p = &PartitionCommit{Topic: topic, Partition: partition}
+		g.partitions[topic][partition] = p
+	}
+	p.Offset = value.Offset
+	p.LastCommit = time.UnixMilli(ts).UTC().Format(time.RFC3339)
+	p.Metadata = value.Metadata
+	p.Commits++
+}
+
+// metadata replaces the group's metadata with the latest record
+func (g *groupHistory) metadata(value *groupMetadataValue) {
+	g.report.Deleted = false
+	g.report.ProtocolType = value.ProtocolType
+	g.report.Protocol = value.Protocol
+	g.report.Generation = value.Generation
+	g.report.Leader = value.Leader
+	g.report.StateChanged = ""
+	if value.CurrentStateTimestamp > 0 {
+		g.report.StateChanged = time.UnixMilli(value.CurrentStateTimestamp).UTC().Format(time.RFC3339)
+	}
+
+	g.report.Members = make([]CommitHistoryMember, 0, len(value.Members))
+	for _, m := range value.Members {
+		member := CommitHistoryMember{
+			MemberID:           m.MemberID,
+			GroupInstanceID:    m.GroupInstanceID,
+			ClientID:           m.ClientID,
+			ClientHost:         m.ClientHost,
+			SessionTimeoutMs:   m.SessionTimeout,
+			RebalanceTimeoutMs: m.RebalanceTimeout,
+		}
+		if value.ProtocolType == "consumer" {
+			member.Topics = memberTopics(m)
+		}
+		g.report.Members = append(g.report.Members, member)
+	}
+}
+
+// memberTopics returns the topics assigned to a consumer, or its subscription before the
+// first assignment
+func memberTopics(m groupMemberValue) []string {
+	desc := &sarama.GroupMemberDescription{MemberMetadata: m.Subscription, MemberAssignment: m.Assignment}
+	var topics []string
+	if assignment, err := desc.GetMemberAssignment(); err == nil && assignment != nil {
+		for topic := range assignment.Topics {
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) == 0 {
+		if subscription, err := desc.GetMemberMetadata(); err == nil && subscription != nil {
+			topics = append(topics, subscription.Topics...)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// finish completes the group's report
+func (g *groupHistory) finish(now time.Time, staleAfter time.Duration) GroupCommitHistory {
+	r := g.report
+	if r.Members == nil {
+		r.Members = make([]CommitHistoryMember, 0)
+	}
+	r.Partitions = make([]PartitionCommit, 0)
+	for _, partitions := range g.partitions {
+		for _, p := range partitions {
+			r.Partitions = append(r.Partitions, *p)
+		}
+	}
+	sort.Slice(r.Partitions, func(i, j int) bool {
+		if r.Partitions[i].Topic != r.Partitions[j].Topic {
+			return r.Partitions[i].Topic < r.Partitions[j].Topic
+		}
+		return r.Partitions[i].Partition < r.Partitions[j].Partition
+	})
+
+	if g.last > 0 {
+		last := time.UnixMilli(g.last)
+		r.LastCommit = last.UTC().Format(time.RFC3339)
+		r.FirstCommit = time.UnixMilli(g.first).UTC().Format(time.RFC3339)
+		if since := now.Sub(last); since > 0 {
+			r.SinceLastCommitSeconds = since.Seconds()
+		}
+		if r.Commits > 1 {
+			r.AvgCommitIntervalSeconds = float64(g.last-g.first) / 1000 / float64(r.Commits-1)
+		}
+	}
+	if staleAfter > 0 && !r.Deleted {
+		r.Stale = g.last == 0 || now.Sub(time.UnixMilli(g.last)) > staleAfter
+	}
+	return r
+}
+
+// printCommitHistory prints the group counts and the stale groups
+func printCommitHistory(report *CommitHistoryReport) {
+	var stale, deleted int
+	for _, g := range report.Groups {
+		if g.Stale {
+			stale++
+		}
+		if g.Deleted {
+			deleted++
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Commit History (%s)\n", consumerOffsetsTopic)
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("Records read:     %d from %d partitions", report.RecordsRead, report.PartitionsRead)
+	if report.Truncated {
+		fmt.Print(" (older records skipped)")
+	}
+	fmt.Println()
+	if report.UndecodedRecords > 0 {
+		fmt.Printf("Not decoded:      %d (newer record versions)\n", report.UndecodedRecords)
+	}
+	fmt.Printf("Groups:           %d (%d deleted)\n", len(report.Groups), deleted)
+	fmt.Printf("Stale groups:     %d (no commit for %s)\n", stale, formatAge(report.StaleAfterSeconds))
+
+	printed := 0
+	for _, g := range report.Groups {
+		if !g.Stale {
+			continue
+		}
+		if printed == commitHistoryPrintLimit {
+			fmt.Printf("  ... and %d more, see the report\n", stale-printed)
+			break
+		}
+		printed++
+		last := "never"
+		if g.LastCommit != "" {
+			last = formatAge(g.SinceLastCommitSeconds) + " ago"
+		}
+		fmt.Printf("  %-50s last commit %-12s %d members, %d partitions\n", g.Group, last, len(g.Members), len(g.Partitions))
+	}
+	fmt.Println()
+}
+
+// saveCommitHistory writes the commit history report to a JSON file
+func saveCommitHistory(report *CommitHistoryReport, filename string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal commit history: %w", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -1,6 +1,9 @@
 #!/bin/bash
 # Kafka Cluster Comparison Script
 # Compare two clusters to validate migration
+#
+# Source topics are matched by target_name when the source inventory was
+# collected with -rename-map (e.g. "orders" -> "source.orders" for MirrorMaker 2)
 
 if [ "$#" -ne 2 ]; then
     echo "Usage: $0 <cluster1.json> <cluster2.json>"
@@ -83,8 +86,8 @@ echo "🔍 Topic-by-Topic Comparison:"
 echo "-----------------------------"
 
 # Compare topic message counts
-jq -r '.topics[] | "\(.name)|\(.partitions)|\(.total_messages)"' "$SOURCE" > /tmp/source_topics.txt
-jq -r '.topics[] | "\(.name)|\(.partitions)|\(.total_messages)"' "$TARGET" > /tmp/target_topics.txt
+jq -r '.topics[] | "\(.target_name // .name)|\(.partitions)|\(.total_messages)"' "$SOURCE" | sort -t'|' -k1,1 > /tmp/source_topics.txt
+jq -r '.topics[] | "\(.name)|\(.partitions)|\(.total_messages)"' "$TARGET" | sort -t'|' -k1,1 > /tmp/target_topics.txt
 
 echo "Top 10 topics by message count:"
 echo ""
@@ -103,7 +106,7 @@ echo ""
 echo "📋 Missing Topics:"
 echo "------------------"
 
-comm -23 <(jq -r '.topics[].name' "$SOURCE" | sort) <(jq -r '.topics[].name' "$TARGET" | sort) > /tmp/missing_topics.txt
+comm -23 <(jq -r '.topics[] | .target_name // .name' "$SOURCE" | sort) <(jq -r '.topics[].name' "$TARGET" | sort) > /tmp/missing_topics.txt
 MISSING_COUNT=$(wc -l < /tmp/missing_topics.txt)
 
 if [ "$MISSING_COUNT" -eq 0 ]; then
@@ -121,7 +124,7 @@ echo ""
 echo "📋 Extra Topics:"
 echo "----------------"
 
-comm -13 <(jq -r '.topics[].name' "$SOURCE" | sort) <(jq -r '.topics[].name' "$TARGET" | sort) > /tmp/extra_topics.txt
+comm -13 <(jq -r '.topics[] | .target_name // .name' "$SOURCE" | sort) <(jq -r '.topics[].name' "$TARGET" | sort) > /tmp/extra_topics.txt
 EXTRA_COUNT=$(wc -l < /tmp/extra_topics.txt)
 
 if [ "$EXTRA_COUNT" -eq 0 ]; then
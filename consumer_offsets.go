@@ -12,51 +12,74 @@ import (
 	"github.com/IBM/sarama"
 )
 
-// fetchConsumerOffsets retrieves current offset positions for all consumer groups
-func fetchConsumerOffsets(admin sarama.ClusterAdmin, groups []ConsumerGroupInfo, cluster string) (*ConsumerOffsetsBackup, error) {
+// fetchConsumerOffsets retrieves the committed offsets of all consumer groups, including groups
+// without active members, for the topics selected by topicFilter. The log-end offset of each
+// partition is recorded so the lag at backup time is preserved.
+func fetchConsumerOffsets(admin sarama.ClusterAdmin, client sarama.Client, groups []ConsumerGroupInfo, topicFilter *NameFilter, cluster string) (*ConsumerOffsetsBackup, error) {
 	backup := &ConsumerOffsetsBackup{
 		Timestamp:      time.Now().UTC().Format(time.RFC3339),
 		Cluster:        cluster,
 		ConsumerGroups: make([]ConsumerGroupOffsets, 0),
 	}
 
+	// Log-end offsets are shared by all groups reading a partition
+	type topicPartition struct {
+		topic     string
+		partition int32
+	}
+	logEndOffsets := make(map[topicPartition]int64)
+	logEndOffset := func(topic string, partition int32) int64 {
+		tp := topicPartition{topic, partition}
+		if offset, ok := logEndOffsets[tp]; ok {
+			return offset
+		}
+		offset, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			log.Printf("Warning: Could not get log-end offset for topic %s partition %d: %v", topic, partition, err)
+			offset = -1
+		}
+		logEndOffsets[tp] = offset
+		return offset
+	}
+
 	for _, group := range groups {
-		if len(group.Topics) == 0 {
+		offsets, err := fetchCommittedOffsets(admin, group.Name)
+		if err != nil {
+			log.Printf("Warning: Could not fetch offsets for group %s: %v", group.Name, err)
 			continue
 		}
 
 		groupOffsets := ConsumerGroupOffsets{
-			Group:     group.Name,
-			Topics:    make(map[string][]PartitionOffset),
-			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Group:        group.Name,
+			State:        group.State,
+			ProtocolType: group.ProtocolType,
+			Topics:       make(map[string][]PartitionOffset),
+			Timestamp:    time.Now().UTC().Format(time.RFC3339),
 		}
 
-		// Fetch offsets for each topic
-		for _, topic := range group.Topics {
-			offsets, err := admin.ListConsumerGroupOffsets(group.Name, map[string][]int32{topic: nil})
-			if err != nil {
-				log.Printf("Warning: Could not fetch offsets for group %s, topic %s: %v", group.Name, topic, err)
+		for topic, partitions := range offsets {
+			if !topicFilter.Match(topic) {
 				continue
 			}
 
-			if block, ok := offsets.Blocks[topic]; ok {
-				partitionOffsets := make([]PartitionOffset, 0)
-				for partition, offsetInfo := range block {
-					partitionOffsets = append(partitionOffsets, PartitionOffset{
-						Partition: int(partition),
-						Offset:    offsetInfo.Offset,
-					})
+			partitionOffsets := make([]PartitionOffset, 0, len(partitions))
+			for partition, offset := range partitions {
+				po := PartitionOffset{
+					Partition:    int(partition),
+					Offset:       offset,
+					LogEndOffset: logEndOffset(topic, partition),
 				}
-
-				// Sort by partition number
-				sort.Slice(partitionOffsets, func(i, j int) bool {
-					return partitionOffsets[i].Partition < partitionOffsets[j].Partition
-				})
-
-				if len(partitionOffsets) > 0 {
-					groupOffsets.Topics[topic] = partitionOffsets
+				if po.LogEndOffset > offset {
+					po.Lag = po.LogEndOffset - offset
 				}
+				partitionOffsets = append(partitionOffsets, po)
 			}
+
+			// Sort by partition number
+			sort.Slice(partitionOffsets, func(i, j int) bool {
+				return partitionOffsets[i].Partition < partitionOffsets[j].Partition
+			})
+			groupOffsets.Topics[topic] = partitionOffsets
 		}
 
 		if len(groupOffsets.Topics) > 0 {
@@ -67,6 +90,33 @@ func fetchConsumerOffsets(admin sarama.ClusterAdmin, groups []ConsumerGroupInfo,
 	return backup, nil
 }
 
+// fetchCommittedOffsets returns all committed offsets of a group by topic and partition,
+// including topics no member is currently assigned
+func fetchCommittedOffsets(admin sarama.ClusterAdmin, group string) (map[string]map[int32]int64, error) {
+	resp, err := admin.ListConsumerGroupOffsets(group, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Err != sarama.ErrNoError {
+		return nil, resp.Err
+	}
+
+	offsets := make(map[string]map[int32]int64)
+	for topic, partitions := range resp.Blocks {
+		for partition, block := range partitions {
+			// -1: no offset committed for this partition
+			if block.Err != sarama.ErrNoError || block.Offset < 0 {
+				continue
+			}
+			if offsets[topic] == nil {
+				offsets[topic] = make(map[int32]int64)
+			}
+			offsets[topic][partition] = block.Offset
+		}
+	}
+	return offsets, nil
+}
+
 // saveConsumerOffsetsToFile saves consumer group offsets to a JSON file
 func saveConsumerOffsetsToFile(backup *ConsumerOffsetsBackup, filename string) error {
 	data, err := json.MarshalIndent(backup, "", "  ")
@@ -81,8 +131,28 @@ func saveConsumerOffsetsToFile(backup *ConsumerOffsetsBackup, filename string) e
 	return nil
 }
 
+// loadConsumerOffsets reads a backup saved with -save-offsets
+func loadConsumerOffsets(filename string) (*ConsumerOffsetsBackup, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offsets backup: %w", err)
+	}
+	var backup ConsumerOffsetsBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse offsets backup %s: %w", filename, err)
+	}
+	return &backup, nil
+}
+
+// RestoreOptions configure generateRestoreOffsetsScript
+type RestoreOptions struct {
+	CommandConfig string        // --command-config file for kafka-consumer-groups.sh
+	Renames       *TopicRenames // restore offsets on the renamed topics, if set
+	Dialect       string        // CLI used by the script, see parseScriptDialect
+}
+
 // generateRestoreOffsetsScript generates a shell script to restore consumer group offsets
-func generateRestoreOffsetsScript(backup *ConsumerOffsetsBackup, filename string) error {
+func generateRestoreOffsetsScript(backup *ConsumerOffsetsBackup, filename string, opts RestoreOptions) error {
 	f, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
@@ -101,21 +171,13 @@ func generateRestoreOffsetsScript(backup *ConsumerOffsetsBackup, filename string
 #
 # Usage:
 #   1. Edit BOOTSTRAP_SERVERS to point to your target cluster
-#   2. Add authentication flags if needed (--command-config, etc.)
+#   2. Add authentication flags if needed (%s)
 #   3. Run: chmod +x %s && ./%s
 #
 
 set -e  # Exit on error
 
-# Target cluster configuration
-BOOTSTRAP_SERVERS="localhost:9092"  # CHANGE THIS
-# Uncomment and configure if authentication is needed:
-# COMMAND_CONFIG="--command-config client.properties"
-COMMAND_CONFIG=""
-
-# Kafka consumer groups command (adjust path if needed)
-KAFKA_CONSUMER_GROUPS="kafka-consumer-groups.sh"
-
+%s
 echo "========================================"
 echo "Restoring offsets for %d consumer groups"
 echo "Target: $BOOTSTRAP_SERVERS"
@@ -125,18 +187,43 @@ echo ""
 RESTORED=0
 FAILED=0
 
-`, backup.Timestamp, backup.Cluster, len(backup.ConsumerGroups), filename, filename, len(backup.ConsumerGroups))
+`, backup.Timestamp, backup.Cluster, len(backup.ConsumerGroups), restoreAuthHint(opts.Dialect), filename, filename, restoreToolLines(opts.Dialect, opts.CommandConfig), len(backup.ConsumerGroups))
 
 	// Write offset restoration commands for each group
 	for groupIdx, group := range backup.ConsumerGroups {
-		fmt.Fprintf(w, "# Consumer Group %d/%d: %s\n", groupIdx+1, len(backup.ConsumerGroups), group.Group)
-		fmt.Fprintf(w, "echo \"[%d/%d] Restoring offsets for consumer group: %s\"\n", groupIdx+1, len(backup.ConsumerGroups), group.Group)
+		fmt.Fprintf(w, "# Consumer Group %d/%d: %s\n", groupIdx+1, len(backup.ConsumerGroups), scriptCommentName(group.Group))
+		if group.State != "" {
+			fmt.Fprintf(w, "# State at backup: %s, protocol type: %s\n", group.State, scriptCommentName(group.ProtocolType))
+		}
+		fmt.Fprintf(w, "echo \"[%d/%d] Restoring offsets for consumer group:\" %s\n", groupIdx+1, len(backup.ConsumerGroups), shellQuote(group.Group))
 
-		for topic, partitions := range group.Topics {
-			fmt.Fprintf(w, "echo \"  Topic: %s (%d partitions)\"\n", topic, len(partitions))
+		sources := make([]string, 0, len(group.Topics))
+		for source := range group.Topics {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		for topicIdx, source := range sources {
+			partitions := group.Topics[source]
+			topic := opts.Renames.Rename(source)
+			if topic != source {
+				fmt.Fprintf(w, "# Source topic: %s\n", scriptCommentName(source))
+			}
+			fmt.Fprintf(w, "echo \"  Topic:\" %s \"(%d partitions)\"\n", shellQuote(topic), len(partitions))
+
+			if opts.Dialect == DialectRpk {
+				file := fmt.Sprintf("\"$OFFSETS_DIR/offsets-%d-%d.txt\"", groupIdx+1, topicIdx+1)
+				fmt.Fprintf(w, "%s\n", rpkSeekCommand(group.Group, topic, partitions, file))
+				continue
+			}
 
 			// Create JSON for offset reset
-			fmt.Fprintf(w, "cat > /tmp/offsets_%s_%s.json << 'OFFSET_EOF'\n", group.Group, topic)
+			file := fmt.Sprintf("\"$OFFSETS_DIR/offsets-%d-%d.json\"", groupIdx+1, topicIdx+1)
+			topicJSON, err := json.Marshal(topic)
+			if err != nil {
+				return fmt.Errorf("failed to encode topic name: %w", err)
+			}
+			fmt.Fprintf(w, "cat > %s << 'OFFSET_EOF'\n", file)
 			fmt.Fprintf(w, "{\n")
 			fmt.Fprintf(w, "  \"partitions\": [\n")
 
@@ -145,8 +232,8 @@ FAILED=0
 				if i == len(partitions)-1 {
 					comma = ""
 				}
-				fmt.Fprintf(w, "    {\"topic\": \"%s\", \"partition\": %d, \"offset\": %d}%s\n",
-					topic, partition.Partition, partition.Offset, comma)
+				fmt.Fprintf(w, "    {\"topic\": %s, \"partition\": %d, \"offset\": %d}%s\n",
+					topicJSON, partition.Partition, partition.Offset, comma)
 			}
 
 			fmt.Fprintf(w, "  ]\n")
@@ -155,18 +242,17 @@ FAILED=0
 
 			// Execute offset reset
 			fmt.Fprintf(w, "if $KAFKA_CONSUMER_GROUPS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG \\\n")
-			fmt.Fprintf(w, "  --group \"%s\" \\\n", group.Group)
-			fmt.Fprintf(w, "  --topic \"%s\" \\\n", topic)
+			fmt.Fprintf(w, "  --group %s \\\n", shellQuote(group.Group))
+			fmt.Fprintf(w, "  --topic %s \\\n", shellQuote(topic))
 			fmt.Fprintf(w, "  --reset-offsets \\\n")
-			fmt.Fprintf(w, "  --from-json-file /tmp/offsets_%s_%s.json \\\n", group.Group, topic)
+			fmt.Fprintf(w, "  --from-json-file %s \\\n", file)
 			fmt.Fprintf(w, "  --execute; then\n")
 			fmt.Fprintf(w, "  echo \"    ✓ Restored %d partitions\"\n", len(partitions))
-			fmt.Fprintf(w, "  ((RESTORED++))\n")
+			fmt.Fprintf(w, "  RESTORED=$((RESTORED + 1))\n")
 			fmt.Fprintf(w, "else\n")
 			fmt.Fprintf(w, "  echo \"    ✗ Failed to restore offsets\"\n")
-			fmt.Fprintf(w, "  ((FAILED++))\n")
+			fmt.Fprintf(w, "  FAILED=$((FAILED + 1))\n")
 			fmt.Fprintf(w, "fi\n")
-			fmt.Fprintf(w, "rm -f /tmp/offsets_%s_%s.json\n", group.Group, topic)
 			fmt.Fprintf(w, "\n")
 		}
 
@@ -181,8 +267,7 @@ echo "  Failed: $FAILED topics"
 echo "========================================"
 echo ""
 echo "Note: Verify offsets were restored correctly:"
-echo "  $KAFKA_CONSUMER_GROUPS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --group <group-name> --describe"
-`)
+%s`, restoreVerifyLine(opts.Dialect))
 
 	if err := os.Chmod(filename, 0755); err != nil {
 		return fmt.Errorf("failed to make script executable: %w", err)
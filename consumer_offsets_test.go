@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRestoreOffsetsScriptQuotesNames(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	for _, dialect := range []string{DialectKafka, DialectConfluent} {
+		dir := t.TempDir()
+		marker := filepath.Join(dir, "pwned")
+		hostile := []string{
+			"a\"; touch " + marker + "; \"",
+			"x/$(touch " + marker + ")",
+			"`touch " + marker + "`",
+			"it's \"quoted\" $HOME",
+			"line\ntouch " + marker,
+		}
+		backup := &ConsumerOffsetsBackup{Timestamp: "2024-01-02T03:04:05Z", Cluster: "kafka:9092"}
+		for _, name := range hostile {
+			backup.ConsumerGroups = append(backup.ConsumerGroups, ConsumerGroupOffsets{
+				Group:        name,
+				State:        "Empty",
+				ProtocolType: name,
+				Topics: map[string][]PartitionOffset{
+					name:     {{Partition: 0, Offset: 42}},
+					"orders": {{Partition: 0, Offset: 7}, {Partition: 1, Offset: 8}},
+				},
+			})
+		}
+
+		script := filepath.Join(dir, "restore.sh")
+		if err := generateRestoreOffsetsScript(backup, script, RestoreOptions{Dialect: dialect}); err != nil {
+			t.Fatalf("%s: generateRestoreOffsetsScript: %v", dialect, err)
+		}
+
+		// The stand-in records its arguments and the offsets file, NUL-separated
+		args := filepath.Join(dir, "args")
+		files := filepath.Join(dir, "files")
+		fake := "#!/bin/bash\n" +
+			"for a in \"$@\"; do printf '%s\\0' \"$a\" >> " + shellQuote(args) + "; done\n" +
+			"while [ $# -gt 0 ]; do\n" +
+			"  if [ \"$1\" = --from-json-file ]; then cat \"$2\" >> " + shellQuote(files) + "; printf '\\0' >> " + shellQuote(files) + "; fi\n" +
+			"  shift\n" +
+			"done\n"
+		if err := os.WriteFile(filepath.Join(dir, "kafka-consumer-groups.sh"), []byte(fake), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := exec.Command(bash, script)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v\n%s", dialect, err, out)
+		}
+		if _, err := os.Stat(marker); err == nil {
+			t.Fatalf("%s: a group or topic name was executed", dialect)
+		}
+
+		data, err := os.ReadFile(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received := strings.Split(string(data), "\x00")
+		for _, name := range hostile {
+			group, topic := false, false
+			for i := 0; i+1 < len(received); i++ {
+				if received[i] == "--group" && received[i+1] == name {
+					group = true
+				}
+				if received[i] == "--topic" && received[i+1] == name {
+					topic = true
+				}
+			}
+			if !group || !topic {
+				t.Errorf("%s: %q was not passed verbatim as group and topic", dialect, name)
+			}
+		}
+
+		data, err = os.ReadFile(files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		topics := make(map[string]int)
+		for _, file := range strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00") {
+			var offsets struct {
+				Partitions []struct {
+					Topic string `json:"topic"`
+				} `json:"partitions"`
+			}
+			if err := json.Unmarshal([]byte(file), &offsets); err != nil {
+				t.Fatalf("%s: invalid offsets file: %v\n%s", dialect, err, file)
+			}
+			for _, p := range offsets.Partitions {
+				topics[p.Topic]++
+			}
+		}
+		for _, name := range hostile {
+			if topics[name] != 1 {
+				t.Errorf("%s: topic %q in %d offsets files, want 1", dialect, name, topics[name])
+			}
+		}
+		if topics["orders"] != 2*len(hostile) {
+			t.Errorf("%s: %d orders partitions restored, want %d", dialect, topics["orders"], 2*len(hostile))
+		}
+	}
+}
+
+func TestGenerateRestoreOffsetsScriptSortsTopics(t *testing.T) {
+	topics := make(map[string][]PartitionOffset)
+	for _, name := range []string{"payments", "audit", "orders", "billing", "events"} {
+		topics[name] = []PartitionOffset{{Partition: 0, Offset: 1}}
+	}
+	backup := &ConsumerOffsetsBackup{ConsumerGroups: []ConsumerGroupOffsets{{Group: "billing", Topics: topics}}}
+
+	script := filepath.Join(t.TempDir(), "restore.sh")
+	var first string
+	for i := 0; i < 5; i++ {
+		if err := generateRestoreOffsetsScript(backup, script, RestoreOptions{}); err != nil {
+			t.Fatalf("generateRestoreOffsetsScript: %v", err)
+		}
+		data, err := os.ReadFile(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = string(data)
+			continue
+		}
+		if string(data) != first {
+			t.Fatal("the restore script differs between runs")
+		}
+	}
+	if a, p := strings.Index(first, "--topic 'audit'"), strings.Index(first, "--topic 'payments'"); a < 0 || p < a {
+		t.Errorf("topics are not in sorted order:\n%s", first)
+	}
+}
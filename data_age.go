@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// dataAgeTail is the number of records before the high watermark read for the newest timestamp.
+// The last offsets can be transaction markers, and CreateTime timestamps are not ordered.
+const dataAgeTail = 16
+
+// dataAgeFetchBytes is the fetch size for data age; the broker returns at least one batch anyway
+const dataAgeFetchBytes = 64 * 1024
+
+// maxClockSkew is how far a record timestamp may be ahead of now before it is flagged
+const maxClockSkew = time.Hour
+
+// defaultSegmentMs is Kafka's default log.roll.hours (7 days). Retention only deletes closed
+// segments, so records can outlive retention.ms by up to segment.ms.
+const defaultSegmentMs = 7 * 24 * 60 * 60 * 1000
+
+// Data age findings
+const (
+	AgeBeyondRetention  = "beyond-retention"  // oldest record older than retention.ms plus segment.ms
+	AgeStalled          = "stalled"           // no writes for -stalled-after
+	AgeFutureTimestamps = "future-timestamps" // producer clocks ahead; time retention keeps these records longer
+)
+
+// DataAge is the age of the data in a topic, from the first and last record of each partition
+type DataAge struct {
+	Oldest                string         `json:"oldest_timestamp"`
+	Newest                string         `json:"newest_timestamp"`
+	OldestAgeSeconds      float64        `json:"oldest_age_seconds"`
+	SpanSeconds           float64        `json:"age_span_seconds"`
+	SinceLastWriteSeconds float64        `json:"since_last_write_seconds"`
+	RetentionMs           int64          `json:"retention_ms"`
+	RetentionUsed         float64        `json:"retention_used,omitempty"` // oldest age / retention.ms
+	Findings              []string       `json:"findings,omitempty"`
+	Partitions            []PartitionAge `json:"partitions,omitempty"`
+}
+
+// PartitionAge is the timestamp of the first and last record of a partition
+type PartitionAge struct {
+	Partition int32  `json:"partition"`
+	Oldest    string `json:"oldest_timestamp"`
+	Newest    string `json:"newest_timestamp"`
+}
+
+// measureDataAge sets the data age of every non-empty topic. Records are read with plain
+// fetch requests, so no consumer group is joined.
+func measureDataAge(client sarama.Client, info *KafkaClusterInfo, defaults retentionDefaults, stalledAfter time.Duration, now time.Time) {
+	for i := range info.Topics {
+		topic := &info.Topics[i]
+		if topic.TotalMessages == 0 {
+			continue
+		}
+		age, err := topicDataAge(client, *topic, defaults, stalledAfter, now)
+		if err != nil {
+			log.Printf("Warning: Could not read data age of topic %s: %v", topic.Name, err)
+			continue
+		}
+		topic.DataAge = age
+	}
+}
+
+// topicDataAge reads the first record at the low watermark and the last records before the
+// high watermark of each partition. Returns nil if no record has a timestamp.
+func topicDataAge(client sarama.Client, topic TopicInfo, defaults retentionDefaults, stalledAfter time.Duration, now time.Time) (*DataAge, error) {
+	var oldest, newest time.Time
+	age := &DataAge{}
+
+	for partition := int32(0); partition < int32(topic.Partitions); partition++ {
+		low, err := client.GetOffset(topic.Name, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		high, err := client.GetOffset(topic.Name, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+		if high <= low {
+			continue
+		}
+
+		first, err := fetchRecords(client, topic.Name, partition, low, high, 1, dataAgeFetchBytes)
+		if err != nil {
+			return nil, fmt.Errorf("partition %d: %v", partition, err)
+		}
+		start := high - dataAgeTail
+		if start < low {
+			start = low
+		}
+		last, err := fetchRecords(client, topic.Name, partition, start, high, dataAgeTail, dataAgeFetchBytes)
+		if err != nil {
+			return nil, fmt.Errorf("partition %d: %v", partition, err)
+		}
+
+		var p PartitionAge
+		if len(first.Records) > 0 && validTimestamp(first.Records[0].Timestamp) {
+			p.Oldest = first.Records[0].Timestamp.UTC().Format(time.RFC3339)
+			if oldest.IsZero() || first.Records[0].Timestamp.Before(oldest) {
+				oldest = first.Records[0].Timestamp
+			}
+		}
+		var partitionNewest time.Time
+		for _, rec := range last.Records {
+			if validTimestamp(rec.Timestamp) && rec.Timestamp.After(partitionNewest) {
+				partitionNewest = rec.Timestamp
+			}
+		}
+		if !partitionNewest.IsZero() {
+			p.Newest = partitionNewest.UTC().Format(time.RFC3339)
+			if partitionNewest.After(newest) {
+				newest = partitionNewest
+			}
+		}
+		if p.Oldest != "" || p.Newest != "" {
+			p.Partition = partition
+			age.Partitions = append(age.Partitions, p)
+		}
+	}
+
+	if oldest.IsZero() || newest.IsZero() {
+		return nil, nil
+	}
+	age.Oldest = oldest.UTC().Format(time.RFC3339)
+	age.Newest = newest.UTC().Format(time.RFC3339)
+	age.OldestAgeSeconds = now.Sub(oldest).Seconds()
+	age.SpanSeconds = newest.Sub(oldest).Seconds()
+	if since := now.Sub(newest); since > 0 {
+		age.SinceLastWriteSeconds = since.Seconds()
+	}
+
+	retention := topicRetention(topic.Configs, defaults)
+	age.RetentionMs = retention.RetentionMs
+	if strings.Contains(retention.CleanupPolicy, "delete") && retention.RetentionMs > 0 {
+		age.RetentionUsed = age.OldestAgeSeconds * 1000 / float64(retention.RetentionMs)
+		segmentMs := int64(defaultSegmentMs)
+		if v, err := strconv.ParseInt(topic.Configs["segment.ms"], 10, 64); err == nil {
+			segmentMs = v
+		}
+		if age.OldestAgeSeconds*1000 > float64(retention.RetentionMs+segmentMs) {
+			age.Findings = append(age.Findings, AgeBeyondRetention)
+		}
+	}
+	if stalledAfter > 0 && now.Sub(newest) > stalledAfter {
+		age.Findings = append(age.Findings, AgeStalled)
+	}
+	if newest.Sub(now) > maxClockSkew {
+		age.Findings = append(age.Findings, AgeFutureTimestamps)
+	}
+	return age, nil
+}
+
+// validTimestamp reports whether a record has a timestamp; message format v0 records and
+// producers that don't set one use -1
+func validTimestamp(t time.Time) bool {
+	return t.UnixMilli() > 0
+}
+
+// countAgeFindings counts the topics with each data age finding
+func countAgeFindings(topics []TopicInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, topic := range topics {
+		if topic.DataAge == nil {
+			continue
+		}
+		for _, finding := range topic.DataAge.Findings {
+			counts[finding]++
+		}
+	}
+	return counts
+}
+
+// formatAge formats a duration in seconds, e.g. 3d 4h, 2h 10m or 45s
+func formatAge(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	days := int(d.Hours()) / 24
+	switch {
+	case days >= 30:
+		return fmt.Sprintf("%dd", days)
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+// formatRetention formats retention.ms, with -1 as unlimited
+func formatRetention(ms int64) string {
+	if ms < 0 {
+		return "unlimited"
+	}
+	return formatAge(float64(ms) / 1000)
+}
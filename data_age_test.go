@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// dataAgeClient serves one partition whose records have the given timestamps, from offset 0
+func dataAgeClient(t *testing.T, topic string, timestamps ...time.Time) sarama.Client {
+	t.Helper()
+	fetch := &sarama.FetchResponse{Version: 4}
+	for offset, ts := range timestamps {
+		fetch.AddRecordBatchWithTimestamp(topic, 0, nil, sarama.StringEncoder("x"), int64(offset), -1, false, ts)
+	}
+
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(topic, 0, sarama.OffsetOldest, 0).
+			SetOffset(topic, 0, sarama.OffsetNewest, int64(len(timestamps))),
+		"FetchRequest": sarama.NewMockWrapper(fetch),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestTopicDataAge(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name       string
+		configs    map[string]string
+		timestamps []time.Time
+		findings   []string
+		oldestAge  float64 // seconds
+		sinceWrite float64 // seconds
+		used       float64
+	}{
+		{
+			name:       "within retention",
+			timestamps: []time.Time{ago(day), ago(time.Hour), ago(time.Minute)},
+			oldestAge:  day.Seconds(),
+			sinceWrite: 60,
+			used:       1.0 / 7,
+		},
+		{
+			name:       "beyond retention plus segment.ms",
+			configs:    map[string]string{"retention.ms": "86400000", "segment.ms": "86400000"},
+			timestamps: []time.Time{ago(3 * day), ago(time.Minute)},
+			findings:   []string{AgeBeyondRetention},
+			oldestAge:  (3 * day).Seconds(),
+			sinceWrite: 60,
+			used:       3,
+		},
+		{
+			name:       "within the default segment.ms",
+			configs:    map[string]string{"retention.ms": "86400000"},
+			timestamps: []time.Time{ago(3 * day), ago(time.Minute)},
+			oldestAge:  (3 * day).Seconds(),
+			sinceWrite: 60,
+			used:       3,
+		},
+		{
+			name:       "compacted",
+			configs:    map[string]string{"retention.ms": "86400000", "segment.ms": "86400000", "cleanup.policy": "compact"},
+			timestamps: []time.Time{ago(100 * day), ago(time.Minute)},
+			oldestAge:  (100 * day).Seconds(),
+			sinceWrite: 60,
+		},
+		{
+			name:       "stalled",
+			timestamps: []time.Time{ago(2 * day), ago(3 * time.Hour)},
+			findings:   []string{AgeStalled},
+			oldestAge:  (2 * day).Seconds(),
+			sinceWrite: (3 * time.Hour).Seconds(),
+			used:       2.0 / 7,
+		},
+		{
+			name:       "future timestamps",
+			timestamps: []time.Time{ago(day), now.Add(2 * time.Hour), ago(time.Minute)},
+			findings:   []string{AgeFutureTimestamps},
+			oldestAge:  day.Seconds(),
+			used:       1.0 / 7,
+		},
+		{
+			name:       "small clock skew",
+			timestamps: []time.Time{ago(day), now.Add(30 * time.Minute)},
+			oldestAge:  day.Seconds(),
+			used:       1.0 / 7,
+		},
+	}
+
+	for _, tt := range tests {
+		topic := TopicInfo{Name: "orders", Partitions: 1, Configs: tt.configs}
+		age, err := topicDataAge(dataAgeClient(t, topic.Name, tt.timestamps...), topic, kafkaRetentionDefaults, 2*time.Hour, now)
+		if err != nil {
+			t.Fatalf("%s: topicDataAge: %v", tt.name, err)
+		}
+		if age == nil {
+			t.Fatalf("%s: no data age", tt.name)
+		}
+		if !reflect.DeepEqual(age.Findings, tt.findings) {
+			t.Errorf("%s: findings %v, want %v", tt.name, age.Findings, tt.findings)
+		}
+		if age.OldestAgeSeconds != tt.oldestAge || age.SinceLastWriteSeconds != tt.sinceWrite {
+			t.Errorf("%s: oldest %vs ago, last write %vs ago; want %vs and %vs", tt.name,
+				age.OldestAgeSeconds, age.SinceLastWriteSeconds, tt.oldestAge, tt.sinceWrite)
+		}
+		if math.Abs(age.RetentionUsed-tt.used) > 1e-9 {
+			t.Errorf("%s: retention used %v, want %v", tt.name, age.RetentionUsed, tt.used)
+		}
+		if want := tt.timestamps[0].UTC().Format(time.RFC3339); age.Oldest != want || age.Partitions[0].Oldest != want {
+			t.Errorf("%s: oldest %s (partition %s), want %s", tt.name, age.Oldest, age.Partitions[0].Oldest, want)
+		}
+	}
+
+	// Records without timestamps give no data age
+	topic := TopicInfo{Name: "legacy", Partitions: 1}
+	age, err := topicDataAge(dataAgeClient(t, topic.Name, time.Time{}, time.Time{}), topic, kafkaRetentionDefaults, 0, now)
+	if err != nil || age != nil {
+		t.Errorf("without timestamps: got %+v, %v; want no data age", age, err)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported values for -format
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatYAML     = "yaml"
+)
+
+// parseFormats parses the comma-separated -format flag
+func parseFormats(value string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+
+	for _, f := range strings.Split(value, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch f {
+		case "":
+			continue
+		case "md":
+			f = FormatMarkdown
+		case "yml":
+			f = FormatYAML
+		case FormatJSON, FormatCSV, FormatMarkdown, FormatYAML:
+		default:
+			return nil, fmt.Errorf("unknown output format %q (supported: json, csv, markdown, yaml)", f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			formats = append(formats, f)
+		}
+	}
+
+	return formats, nil
+}
+
+// removeFormat returns formats without the given format
+func removeFormat(formats []string, format string) []string {
+	result := make([]string, 0, len(formats))
+	for _, f := range formats {
+		if f != format {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// hasFormat reports whether format was requested
+func hasFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// exportFilename derives an output name from base by replacing its extension,
+// e.g. ("kafka-cluster-info.json", "-topics", ".csv") -> "kafka-cluster-info-topics.csv"
+func exportFilename(base, suffix, ext string) string {
+	return strings.TrimSuffix(base, filepath.Ext(base)) + suffix + ext
+}
+
+// writeClusterExports writes the cluster inventory in each of the requested formats. The sizes
+// CSV is only written when sizes is not nil.
+func writeClusterExports(info *KafkaClusterInfo, sizes *TopicSizesReport, base string, formats []string) error {
+	for _, format := range formats {
+		switch format {
+		case FormatCSV:
+			files := []struct {
+				suffix string
+				write  func(string) error
+			}{
+				{"-topics", func(f string) error { return writeTopicsCSV(info, f) }},
+				{"-brokers", func(f string) error { return writeBrokersCSV(info, f) }},
+				{"-groups", func(f string) error { return writeConsumerGroupsCSV(info, f) }},
+			}
+			if sizes != nil {
+				files = append(files, struct {
+					suffix string
+					write  func(string) error
+				}{"-sizes", func(f string) error { return writeTopicSizesCSV(sizes, f) }})
+			}
+			for _, file := range files {
+				filename := exportFilename(base, file.suffix, ".csv")
+				log.Printf("Writing CSV to %s...", filename)
+				if err := file.write(filename); err != nil {
+					return err
+				}
+			}
+		case FormatMarkdown:
+			filename := exportFilename(base, "", ".md")
+			log.Printf("Writing Markdown to %s...", filename)
+			if err := os.WriteFile(filename, []byte(clusterMarkdown(info)), 0644); err != nil {
+				return err
+			}
+		case FormatYAML:
+			filename := exportFilename(base, "", ".yaml")
+			log.Printf("Writing YAML to %s...", filename)
+			if err := writeYAMLFile(info, filename); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeTopicSizesExports writes the topic sizes report in each of the requested formats
+func writeTopicSizesExports(report *TopicSizesReport, base string, formats []string) error {
+	for _, format := range formats {
+		switch format {
+		case FormatJSON:
+			if err := saveTopicSizesJSON(report, exportFilename(base, "", ".json")); err != nil {
+				return err
+			}
+		case FormatCSV:
+			filename := exportFilename(base, "", ".csv")
+			log.Printf("Writing CSV to %s...", filename)
+			if err := writeTopicSizesCSV(report, filename); err != nil {
+				return err
+			}
+		case FormatMarkdown:
+			filename := exportFilename(base, "", ".md")
+			log.Printf("Writing Markdown to %s...", filename)
+			if err := os.WriteFile(filename, []byte(topicSizesMarkdown(report)), 0644); err != nil {
+				return err
+			}
+		case FormatYAML:
+			filename := exportFilename(base, "", ".yaml")
+			log.Printf("Writing YAML to %s...", filename)
+			if err := writeYAMLFile(report, filename); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeYAMLFile(v interface{}, filename string) error {
+	data, err := marshalYAML(v)
+	if err != nil {
+		return fmt.Errorf("error marshaling YAML: %v", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// writeCSV writes a header and rows to filename
+func writeCSV(filename string, header []string, rows [][]string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writeTopicsCSV(info *KafkaClusterInfo, filename string) error {
+	rows := make([][]string, 0, len(info.Topics))
+	for _, topic := range info.Topics {
+		rows = append(rows, []string{
+			topic.Name,
+			strconv.Itoa(topic.Partitions),
+			strconv.Itoa(topic.ReplicationFactor),
+			strconv.FormatInt(topic.TotalMessages, 10),
+			strings.Join(sortedConfigs(topic.Configs), ";"),
+		})
+	}
+	return writeCSV(filename, []string{"name", "partitions", "replication_factor", "total_messages", "configs"}, rows)
+}
+
+func writeBrokersCSV(info *KafkaClusterInfo, filename string) error {
+	rows := make([][]string, 0, len(info.BrokerDetails))
+	for _, broker := range info.BrokerDetails {
+		rows = append(rows, []string{
+			strconv.Itoa(int(broker.ID)),
+			broker.Address,
+			broker.Version,
+			strconv.Itoa(broker.Partitions),
+			strconv.Itoa(broker.Leaders),
+			strconv.Itoa(broker.UnderReplicated),
+		})
+	}
+	return writeCSV(filename, []string{"id", "address", "version", "partitions", "leaders", "under_replicated_partitions"}, rows)
+}
+
+func writeConsumerGroupsCSV(info *KafkaClusterInfo, filename string) error {
+	rows := make([][]string, 0, len(info.ConsumerGroups))
+	for _, group := range info.ConsumerGroups {
+		rows = append(rows, []string{
+			group.Name,
+			group.State,
+			strconv.Itoa(group.Members),
+			strings.Join(group.Topics, ";"),
+		})
+	}
+	return writeCSV(filename, []string{"name", "state", "members", "topics"}, rows)
+}
+
+func writeTopicSizesCSV(report *TopicSizesReport, filename string) error {
+	rows := make([][]string, 0, len(report.Topics))
+	for _, topic := range report.Topics {
+		rows = append(rows, []string{
+			topic.Topic,
+			strconv.Itoa(topic.Partitions),
+			strconv.FormatInt(topic.TotalSize, 10),
+			topic.TotalSizeStr,
+		})
+	}
+	return writeCSV(filename, []string{"topic", "partitions", "total_size_bytes", "total_size_human"}, rows)
+}
+
+// sortedConfigs returns topic configs as sorted key=value pairs
+func sortedConfigs(configs map[string]string) []string {
+	pairs := make([]string, 0, len(configs))
+	for k, v := range configs {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// markdownTable renders a GitHub/Confluence compatible Markdown table
+func markdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownEscape(cell)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+// clusterMarkdown renders the cluster inventory as Markdown for runbooks and wikis
+func clusterMarkdown(info *KafkaClusterInfo) string {
+	var md strings.Builder
+
+	md.WriteString("# Kafka Cluster Report\n\n")
+	md.WriteString(fmt.Sprintf("Generated: %s  \n", info.Timestamp))
+	md.WriteString(fmt.Sprintf("Cluster: %s\n\n", strings.Join(info.Brokers, ", ")))
+
+	md.WriteString("## Summary\n\n")
+	summary := [][]string{
+		{"Brokers", strconv.Itoa(len(info.BrokerDetails))},
+		{"Topics", strconv.Itoa(info.TotalTopics)},
+		{"Total Partitions", strconv.Itoa(info.TotalPartitions)},
+		{"Total Messages", formatNumber(info.TotalMessages)},
+		{"Consumer Groups", strconv.Itoa(info.TotalConsumerGroups)},
+		{"Under-Replicated Partitions", strconv.Itoa(info.TotalURPs)},
+	}
+	md.WriteString(markdownTable([]string{"Metric", "Value"}, summary))
+
+	md.WriteString("\n## Brokers\n\n")
+	brokerRows := make([][]string, 0, len(info.BrokerDetails))
+	for _, broker := range info.BrokerDetails {
+		brokerRows = append(brokerRows, []string{
+			strconv.Itoa(int(broker.ID)),
+			broker.Address,
+			broker.Version,
+			strconv.Itoa(broker.Partitions),
+			strconv.Itoa(broker.Leaders),
+			strconv.Itoa(broker.UnderReplicated),
+		})
+	}
+	md.WriteString(markdownTable([]string{"Broker ID", "Address", "Version", "Partitions", "Leaders", "Under-Replicated"}, brokerRows))
+
+	md.WriteString("\n## Topics\n\n")
+	topicRows := make([][]string, 0, len(info.Topics))
+	for _, topic := range info.Topics {
+		configStr := strings.Join(sortedConfigs(topic.Configs), ", ")
+		if configStr == "" {
+			configStr = "_Default_"
+		}
+		topicRows = append(topicRows, []string{
+			"`" + topic.Name + "`",
+			strconv.Itoa(topic.Partitions),
+			strconv.Itoa(topic.ReplicationFactor),
+			formatNumber(topic.TotalMessages),
+			configStr,
+		})
+	}
+	md.WriteString(markdownTable([]string{"Topic Name", "Partitions", "Replication Factor", "Total Messages", "Custom Configurations"}, topicRows))
+
+	md.WriteString("\n## Consumer Groups\n\n")
+	groupRows := make([][]string, 0, len(info.ConsumerGroups))
+	for _, group := range info.ConsumerGroups {
+		topicsStr := strings.Join(group.Topics, ", ")
+		if topicsStr == "" {
+			topicsStr = "_None_"
+		}
+		groupRows = append(groupRows, []string{
+			"`" + group.Name + "`",
+			group.State,
+			strconv.Itoa(group.Members),
+			topicsStr,
+		})
+	}
+	md.WriteString(markdownTable([]string{"Group Name", "State", "Members", "Subscribed Topics"}, groupRows))
+
+	return md.String()
+}
+
+// topicSizesMarkdown renders the topic sizes report as Markdown
+func topicSizesMarkdown(report *TopicSizesReport) string {
+	var md strings.Builder
+
+	md.WriteString("# Kafka Topic Sizes Report\n\n")
+	md.WriteString(fmt.Sprintf("Generated: %s  \n", report.Timestamp))
+	md.WriteString(fmt.Sprintf("Cluster: %s\n\n", report.Cluster))
+
+	rows := make([][]string, 0, len(report.Topics))
+	for _, topic := range report.Topics {
+		rows = append(rows, []string{
+			"`" + topic.Topic + "`",
+			strconv.Itoa(topic.Partitions),
+			topic.TotalSizeStr,
+			formatNumber(topic.TotalSize),
+		})
+	}
+	md.WriteString(markdownTable([]string{"Topic", "Partitions", "Total Size", "Size (Bytes)"}, rows))
+
+	md.WriteString("\n## Summary\n\n")
+	md.WriteString(fmt.Sprintf("- Total Topics: %d\n", report.TotalTopics))
+	md.WriteString(fmt.Sprintf("- Total Partitions: %d\n", report.TotalPartitions))
+	md.WriteString(fmt.Sprintf("- Total Size: %s (%s bytes)\n", report.TotalSizeStr, formatNumber(report.TotalSize)))
+	md.WriteString("\nSizes include replication.\n")
+
+	return md.String()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteClusterExportsCSV(t *testing.T) {
+	info := &KafkaClusterInfo{
+		BrokerDetails:  []BrokerInfo{{ID: 1, Address: "kafka-1:9092"}},
+		Topics:         []TopicInfo{{Name: "orders", Partitions: 3, ReplicationFactor: 2}},
+		ConsumerGroups: []ConsumerGroupInfo{{Name: "billing"}},
+	}
+	sizes := &TopicSizesReport{
+		Topics: []TopicSize{{Topic: "orders", Partitions: 3, TotalSize: 2048, TotalSizeStr: "2.00 KB"}},
+	}
+	base := filepath.Join(t.TempDir(), "cluster.json")
+
+	if err := writeClusterExports(info, sizes, base, []string{FormatCSV}); err != nil {
+		t.Fatalf("writeClusterExports: %v", err)
+	}
+	for suffix, want := range map[string]string{
+		"-topics":  "orders",
+		"-brokers": "kafka-1:9092",
+		"-groups":  "billing",
+		"-sizes":   "orders,3,2048,2.00 KB",
+	} {
+		data, err := os.ReadFile(exportFilename(base, suffix, ".csv"))
+		if err != nil {
+			t.Errorf("%s CSV: %v", suffix, err)
+			continue
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s CSV does not contain %q:\n%s", suffix, want, data)
+		}
+	}
+
+	// Without sizes there is no sizes CSV
+	base = filepath.Join(t.TempDir(), "cluster.json")
+	if err := writeClusterExports(info, nil, base, []string{FormatCSV}); err != nil {
+		t.Fatalf("writeClusterExports: %v", err)
+	}
+	if _, err := os.Stat(exportFilename(base, "-sizes", ".csv")); !os.IsNotExist(err) {
+		t.Errorf("unexpected sizes CSV without a sizes report: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// internalTopicPatterns are globs for topics managed by Kafka itself and by well-known
+// ecosystem components, excluded with -exclude-internal
+var internalTopicPatterns = []string{
+	"__*",             // Kafka: __consumer_offsets, __transaction_state, __cluster_metadata
+	"_schemas",        // Schema Registry
+	"_confluent*",     // Confluent Platform, ksqlDB
+	"connect-configs", // Kafka Connect default storage topics
+	"connect-offsets",
+	"connect-status",
+	"*-changelog",   // Kafka Streams state store changelogs
+	"*-repartition", // Kafka Streams repartition topics
+	"*.internal",    // MirrorMaker 2 offset-syncs and checkpoints
+}
+
+// patternList collects glob or regex patterns from a repeatable flag. Values are
+// comma-separated globs, or a single regex when prefixed with "re:".
+type patternList []string
+
+func (p *patternList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patternList) Set(value string) error {
+	if strings.HasPrefix(value, "re:") {
+		*p = append(*p, value)
+		return nil
+	}
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*p = append(*p, pattern)
+		}
+	}
+	return nil
+}
+
+// NameFilter selects topics or consumer groups by include and exclude patterns.
+// A name matches if it matches any include pattern (or there are none) and no exclude pattern.
+// A nil NameFilter matches everything.
+type NameFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// newNameFilter compiles include and exclude patterns. Returns nil if there are none.
+func newNameFilter(include, exclude []string) (*NameFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	f := &NameFilter{}
+	for _, pattern := range include {
+		re, err := compileNamePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, re)
+	}
+	for _, pattern := range exclude {
+		re, err := compileNamePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+// compileNamePattern compiles "re:<regex>" as a regular expression (unanchored, like grep)
+// and anything else as a glob matching the whole name: * any characters, ? one character,
+// [abc] a character class
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", expr, err)
+		}
+		return re, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid glob %q: unterminated [", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// Match reports whether name passes the filter
+func (f *NameFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	for _, re := range f.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterNames returns the names that pass the filter, keeping their order
+func (f *NameFilter) filterNames(names []string) []string {
+	if f == nil {
+		return names
+	}
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if f.Match(name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// filterTopicSizes drops topics that do not pass the filter and recomputes the totals
+func filterTopicSizes(report *TopicSizesReport, f *NameFilter) *TopicSizesReport {
+	if f == nil {
+		return report
+	}
+
+	filtered := *report
+	filtered.Topics = nil
+	filtered.TotalSize = 0
+	filtered.TotalPartitions = 0
+	for _, topic := range report.Topics {
+		if !f.Match(topic.Topic) {
+			continue
+		}
+		filtered.Topics = append(filtered.Topics, topic)
+		filtered.TotalSize += topic.TotalSize
+		filtered.TotalPartitions += topic.Partitions
+	}
+	filtered.TotalTopics = len(filtered.Topics)
+	filtered.TotalSizeStr = formatBytes(filtered.TotalSize)
+
+	// Log directory sizes are not split by topic. They are kept when the broker lost no
+	// topics, or when a single log directory holds everything that is left.
+	filtered.Brokers = nil
+	for _, broker := range report.Brokers {
+		b := BrokerSize{Broker: broker.Broker, Topics: make(map[string]int64)}
+		for topic, size := range broker.Topics {
+			if f.Match(topic) {
+				b.Topics[topic] = size
+				b.TotalSize += size
+			}
+		}
+		switch {
+		case len(b.Topics) == len(broker.Topics):
+			b.LogDirs = broker.LogDirs
+		case len(broker.LogDirs) == 1:
+			for dir := range broker.LogDirs {
+				b.LogDirs = map[string]int64{dir: b.TotalSize}
+			}
+		}
+		filtered.Brokers = append(filtered.Brokers, b)
+	}
+	return &filtered
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestFilterTopicSizesKeepsLogDirs(t *testing.T) {
+	report := &TopicSizesReport{
+		Topics: []TopicSize{
+			{Topic: "orders", TotalSize: 300, Partitions: 3},
+			{Topic: "payments", TotalSize: 50, Partitions: 1},
+		},
+		Brokers: []BrokerSize{
+			// Only selected topics
+			{Broker: 1, TotalSize: 100, LogDirs: map[string]int64{"/a": 60, "/b": 40}, Topics: map[string]int64{"orders": 100}},
+			// One log directory
+			{Broker: 2, TotalSize: 150, LogDirs: map[string]int64{"/a": 150}, Topics: map[string]int64{"orders": 100, "payments": 50}},
+			// Several log directories holding a dropped topic
+			{Broker: 3, TotalSize: 100, LogDirs: map[string]int64{"/a": 50, "/b": 50}, Topics: map[string]int64{"orders": 100, "payments": 0}},
+		},
+	}
+	filter, err := newNameFilter([]string{"orders"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filtered := filterTopicSizes(report, filter)
+	if filtered.TotalTopics != 1 || filtered.TotalSize != 300 || filtered.TotalPartitions != 3 {
+		t.Errorf("unexpected totals: %d topics, %d bytes, %d partitions", filtered.TotalTopics, filtered.TotalSize, filtered.TotalPartitions)
+	}
+
+	want := []map[string]int64{
+		{"/a": 60, "/b": 40},
+		{"/a": 100},
+		nil,
+	}
+	for i, broker := range filtered.Brokers {
+		if len(broker.LogDirs) != len(want[i]) {
+			t.Errorf("broker %d: log dirs %v, want %v", broker.Broker, broker.LogDirs, want[i])
+			continue
+		}
+		for dir, size := range want[i] {
+			if broker.LogDirs[dir] != size {
+				t.Errorf("broker %d: log dirs %v, want %v", broker.Broker, broker.LogDirs, want[i])
+			}
+		}
+	}
+}
@@ -5,11 +5,11 @@ go 1.21
 require (
 	github.com/IBM/sarama v1.42.1
 	github.com/xdg-go/scram v1.1.2
+	golang.org/x/crypto v0.14.0
 )
 
 require (
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
-	golang.org/x/crypto v0.14.0 // indirect
 	golang.org/x/text v0.13.0 // indirect
 )
 
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+)
+
+// reportTemplate is the built-in HTML report and the default for -template. It is a single
+// self-contained file: styles and scripts are inline so the report works offline and from file:// URLs.
+//
+//go:embed templates/report.html
+var reportTemplate string
+
+// maxChartTopics limits the "largest topics" chart
+const maxChartTopics = 15
+
+// maxDiagramNodes limits the topology diagram in the report; larger clusters get the prefix view
+const maxDiagramNodes = 300
+
+// chartBar is one bar of a horizontal bar chart
+type chartBar struct {
+	Label            string
+	Value            int64
+	Secondary        int64
+	Percent          float64
+	SecondaryPercent float64
+	Warning          bool
+	Detail           string // shown instead of Value when set
+}
+
+// htmlReportData is passed to the report templates. It embeds the cluster info
+// so templates can use {{.Topics}}, {{.TotalURPs}} etc. directly.
+type htmlReportData struct {
+	*KafkaClusterInfo
+	BrokerChart   []chartBar
+	TopTopics     []chartBar
+	Diagram       template.HTML // inline SVG of the topology
+	DiagramTitle  string
+	HasSamples    bool // any topic sampled with -sample-messages
+	HasRates      bool // produce rates measured with -rate-window
+	HasDataAge    bool // data age read with -data-age
+	BusiestTopics []chartBar
+	HotPartitions []string // topic[partition] producing well above its topic's mean
+}
+
+// generateHTMLReport renders the interactive HTML report to filename
+func generateHTMLReport(info *KafkaClusterInfo, filename string) error {
+	tmpl, err := template.New("report").Funcs(templateFuncs(true)).Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("error parsing report template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newHTMLReportData(info)); err != nil {
+		return fmt.Errorf("error rendering report template: %v", err)
+	}
+
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+func newHTMLReportData(info *KafkaClusterInfo) htmlReportData {
+	data := htmlReportData{KafkaClusterInfo: info}
+
+	// Partitions (replicas) and leaders per broker, scaled to the busiest broker
+	var maxReplicas int64
+	for _, broker := range info.BrokerDetails {
+		if int64(broker.Partitions) > maxReplicas {
+			maxReplicas = int64(broker.Partitions)
+		}
+	}
+	for _, broker := range info.BrokerDetails {
+		data.BrokerChart = append(data.BrokerChart, chartBar{
+			Label:            fmt.Sprintf("Broker %d", broker.ID),
+			Value:            int64(broker.Partitions),
+			Secondary:        int64(broker.Leaders),
+			Percent:          percentOf(int64(broker.Partitions), maxReplicas),
+			SecondaryPercent: percentOf(int64(broker.Leaders), maxReplicas),
+			Warning:          broker.UnderReplicated > 0,
+		})
+	}
+
+	// Largest topics by message count
+	topics := make([]TopicInfo, 0, len(info.Topics))
+	for _, topic := range info.Topics {
+		if topic.TotalMessages > 0 {
+			topics = append(topics, topic)
+		}
+	}
+	sort.SliceStable(topics, func(i, j int) bool {
+		return topics[i].TotalMessages > topics[j].TotalMessages
+	})
+	if len(topics) > maxChartTopics {
+		topics = topics[:maxChartTopics]
+	}
+	for _, topic := range topics {
+		data.TopTopics = append(data.TopTopics, chartBar{
+			Label:   topic.Name,
+			Value:   topic.TotalMessages,
+			Percent: percentOf(topic.TotalMessages, topics[0].TotalMessages),
+		})
+	}
+
+	for _, topic := range info.Topics {
+		if topic.Sample != nil {
+			data.HasSamples = true
+		}
+		if topic.ProduceRate != nil {
+			data.HasRates = true
+		}
+		if topic.DataAge != nil {
+			data.HasDataAge = true
+		}
+	}
+
+	// Busiest topics by produce rate, flagging those with hot partitions
+	if data.HasRates {
+		busiest := make([]TopicInfo, 0, len(info.Topics))
+		for _, topic := range info.Topics {
+			if topic.ProduceRate != nil && topic.ProduceRate.MessagesPerSec > 0 {
+				busiest = append(busiest, topic)
+			}
+			if topic.ProduceRate != nil {
+				for _, p := range topic.ProduceRate.HotPartitions {
+					data.HotPartitions = append(data.HotPartitions, fmt.Sprintf("%s[%d]", topic.Name, p))
+				}
+			}
+		}
+		sort.SliceStable(busiest, func(i, j int) bool {
+			return busiest[i].ProduceRate.MessagesPerSec > busiest[j].ProduceRate.MessagesPerSec
+		})
+		if len(busiest) > maxChartTopics {
+			busiest = busiest[:maxChartTopics]
+		}
+		for _, topic := range busiest {
+			rate := topic.ProduceRate
+			detail := formatRate(rate.MessagesPerSec) + "/s"
+			if rate.BytesPerSec > 0 {
+				detail += " · " + formatBytes(int64(rate.BytesPerSec)) + "/s"
+			}
+			data.BusiestTopics = append(data.BusiestTopics, chartBar{
+				Label:   topic.Name,
+				Value:   int64(rate.MessagesPerSec + 0.5),
+				Percent: rate.MessagesPerSec * 100 / busiest[0].ProduceRate.MessagesPerSec,
+				Warning: len(rate.HotPartitions) > 0,
+				Detail:  detail,
+			})
+		}
+	}
+
+	// Topology diagram, collapsed by topic prefix when there are too many nodes
+	if len(info.Topics)+len(info.ConsumerGroups) > 0 {
+		data.DiagramTitle = "Topics and Consumer Groups"
+		graph := buildTopologyGraph(info)
+		if len(info.Topics)+len(info.ConsumerGroups) > maxDiagramNodes {
+			data.DiagramTitle = "Topic Prefixes and Consumer Groups"
+			graph = buildPrefixGraph(info)
+		}
+		data.Diagram = template.HTML(renderSVG(graph))
+	}
+
+	return data
+}
+
+func percentOf(value, max int64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return float64(value) * 100 / float64(max)
+}
+
+// formatCompact formats n as a short human-readable number, e.g. 1.25M
+func formatCompact(n int64) string {
+	switch {
+	case n >= 1000000000000:
+		return fmt.Sprintf("%.2fT", float64(n)/1000000000000)
+	case n >= 1000000000:
+		return fmt.Sprintf("%.2fB", float64(n)/1000000000)
+	case n >= 1000000:
+		return fmt.Sprintf("%.2fM", float64(n)/1000000)
+	case n >= 1000:
+		return fmt.Sprintf("%.2fK", float64(n)/1000)
+	}
+	return fmt.Sprintf("%d", n)
+}
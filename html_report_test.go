@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHTMLReport(t *testing.T) {
+	info := &KafkaClusterInfo{
+		Brokers:       []string{"kafka-1:9092"},
+		BrokerDetails: []BrokerInfo{{ID: 1, Address: "kafka-1:9092", Version: "3.6", Partitions: 2, Leaders: 2}},
+		Topics: []TopicInfo{
+			{
+				Name:              "orders",
+				Partitions:        2,
+				ReplicationFactor: 1,
+				TotalMessages:     300,
+				Configs:           map[string]string{"retention.ms": "86400000"},
+				Skew:              &PartitionSkew{Messages: SkewStats{MaxMeanRatio: 1.33, HottestPartition: 1, HottestShare: 0.66}},
+				ProduceRate:       &ProduceRate{MessagesPerSec: 12.5, PeakMessagesPerSec: 20},
+			},
+			{Name: "payments", Partitions: 1, ReplicationFactor: 1},
+		},
+		ConsumerGroups: []ConsumerGroupInfo{{Name: "billing", Topics: []string{"orders"}, State: "Stable"}},
+		TotalTopics:    2,
+	}
+
+	filename := filepath.Join(t.TempDir(), "report.html")
+	if err := generateHTMLReport(info, filename); err != nil {
+		t.Fatalf("generateHTMLReport: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	// Every row has a cell per column
+	for _, id := range []string{"brokers-table", "topics-table"} {
+		start := strings.Index(html, `id="`+id+`"`)
+		if start < 0 {
+			t.Fatalf("%s is missing", id)
+		}
+		table := html[start : start+strings.Index(html[start:], "</table>")]
+		head, body, _ := strings.Cut(table, "</thead>")
+		columns := len(regexp.MustCompile(`<th[ >]`).FindAllString(head, -1))
+		rows := regexp.MustCompile(`(?s)<tr[^>]*>(.*?)</tr>`).FindAllStringSubmatch(body, -1)
+		if len(rows) == 0 {
+			t.Errorf("%s has no rows", id)
+		}
+		for _, row := range rows {
+			if cells := strings.Count(row[1], "<td"); cells != columns {
+				t.Errorf("%s: row has %d cells for %d columns:\n%s", id, cells, columns, row[1])
+			}
+		}
+	}
+	for _, want := range []string{"kafka-1:9092", "orders", "payments", "billing", "1.33"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
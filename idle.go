@@ -0,0 +1,402 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Reasons a topic or consumer group is reported as idle
+const (
+	IdleNoWrites       = "no-writes"         // high watermarks unchanged over the window
+	IdleNoConsumer     = "no-consumer-group" // no group is assigned to or has committed offsets for the topic
+	IdleEmpty          = "empty"             // no retained messages
+	IdleDeletedTopics  = "deleted-topics"    // committed offsets for topics that no longer exist
+	IdleFarBehind      = "far-behind"        // total lag of at least -idle-lag
+	IdleOffsetsExpired = "offsets-expired"   // committed offsets below the low watermark: retention deleted the data
+	IdleNoOffsets      = "no-offsets"        // no committed offsets at all
+)
+
+// IdleReport lists idle topics and abandoned consumer groups
+type IdleReport struct {
+	Timestamp     string      `json:"timestamp"`
+	Cluster       string      `json:"cluster"`
+	WindowSeconds float64     `json:"window_seconds"`
+	Topics        []IdleTopic `json:"topics"`
+	Groups        []IdleGroup `json:"consumer_groups"`
+}
+
+// IdleTopic is a topic without writes, consumers or data
+type IdleTopic struct {
+	Topic                 string   `json:"topic"`
+	Messages              int64    `json:"messages"`
+	SinceLastWriteSeconds float64  `json:"since_last_write_seconds,omitempty"` // from -data-age
+	Reasons               []string `json:"reasons"`
+	DeleteCandidate       bool     `json:"delete_candidate"`
+}
+
+// IdleGroup is an Empty consumer group whose committed offsets look abandoned
+type IdleGroup struct {
+	Group             string   `json:"group"`
+	State             string   `json:"state"`
+	Topics            []string `json:"topics,omitempty"`
+	DeletedTopics     []string `json:"deleted_topics,omitempty"`
+	Lag               int64    `json:"lag"`
+	ExpiredPartitions int      `json:"expired_partitions,omitempty"`
+	Reasons           []string `json:"reasons"`
+	DeleteCandidate   bool     `json:"delete_candidate"`
+}
+
+// IdleOptions configure findIdleResources
+type IdleOptions struct {
+	Window  time.Duration // high watermark sampling window, unless produce rates were measured
+	MaxLag  int64         // Empty groups with at least this lag are far behind
+	Groups  *NameFilter   // abandoned groups are only reported if selected; all groups count as consumers
+	Cluster string
+}
+
+// findIdleResources checks the selected topics for writes, consumers and data, and the Empty
+// consumer groups for offsets that are far behind, expired or on deleted topics. allTopics is
+// every topic in the cluster, so offsets on filtered-out topics don't count as deleted. Every
+// group in the cluster counts as a consumer, so a topic read only by a filtered-out group is
+// not idle.
+func findIdleResources(client sarama.Client, admin sarama.ClusterAdmin, info *KafkaClusterInfo, allTopics []string, opts IdleOptions) (*IdleReport, error) {
+	report := &IdleReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Cluster:   opts.Cluster,
+		Topics:    make([]IdleTopic, 0),
+		Groups:    make([]IdleGroup, 0),
+	}
+
+	groups, err := admin.ListConsumerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("error listing consumer groups: %w", err)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Topics assigned to members: the selected groups are described already
+	consumed := make(map[string]bool)
+	described := make(map[string]bool, len(info.ConsumerGroups))
+	for _, group := range info.ConsumerGroups {
+		described[group.Name] = true
+		for _, topic := range group.Topics {
+			consumed[topic] = true
+		}
+	}
+	var others []string
+	for _, name := range names {
+		if !described[name] {
+			others = append(others, name)
+		}
+	}
+	if len(others) > 0 {
+		descriptions, err := admin.DescribeConsumerGroups(others)
+		if err != nil {
+			log.Printf("Warning: Could not describe filtered-out consumer groups: %v", err)
+		}
+		for _, desc := range descriptions {
+			for _, member := range desc.Members {
+				assignment, err := member.GetMemberAssignment()
+				if err == nil && assignment != nil {
+					for topic := range assignment.Topics {
+						consumed[topic] = true
+					}
+				}
+			}
+		}
+	}
+
+	// Committed offsets of every group, including topics no member is assigned
+	committed := make(map[string]map[string]map[int32]int64)
+	for _, name := range names {
+		offsets, err := fetchCommittedOffsets(admin, name)
+		if err != nil {
+			log.Printf("Warning: Could not fetch committed offsets for group %s: %v", name, err)
+			continue
+		}
+		committed[name] = offsets
+		for topic := range offsets {
+			consumed[topic] = true
+		}
+	}
+
+	writes, window, err := topicWrites(client, info, opts.Window)
+	if err != nil {
+		return nil, err
+	}
+	report.WindowSeconds = window.Seconds()
+
+	internal, _ := newNameFilter(internalTopicPatterns, nil)
+	for _, topic := range info.Topics {
+		idle := IdleTopic{Topic: topic.Name, Messages: topic.TotalMessages}
+		if topic.DataAge != nil {
+			idle.SinceLastWriteSeconds = topic.DataAge.SinceLastWriteSeconds
+		}
+		noWrites := !writes[topic.Name]
+		if noWrites {
+			idle.Reasons = append(idle.Reasons, IdleNoWrites)
+		}
+		if !consumed[topic.Name] {
+			idle.Reasons = append(idle.Reasons, IdleNoConsumer)
+		}
+		if topic.TotalMessages == 0 {
+			idle.Reasons = append(idle.Reasons, IdleEmpty)
+		}
+		if len(idle.Reasons) == 0 {
+			continue
+		}
+		// Nobody reads it and nobody writes to it (or it holds nothing)
+		idle.DeleteCandidate = !consumed[topic.Name] && (noWrites || topic.TotalMessages == 0) &&
+			!internal.Match(topic.Name)
+		report.Topics = append(report.Topics, idle)
+	}
+
+	exists := make(map[string]bool, len(allTopics))
+	for _, name := range allTopics {
+		exists[name] = true
+	}
+	for _, group := range info.ConsumerGroups {
+		if group.State != "Empty" || !opts.Groups.Match(group.Name) {
+			continue
+		}
+		offsets, ok := committed[group.Name]
+		if !ok {
+			continue
+		}
+		idle := groupIdleness(client, group, offsets, exists)
+		if idle.Lag >= opts.MaxLag && opts.MaxLag > 0 {
+			idle.Reasons = append(idle.Reasons, IdleFarBehind)
+		}
+		if len(idle.Reasons) == 0 {
+			continue
+		}
+		idle.DeleteCandidate = true
+		report.Groups = append(report.Groups, idle)
+	}
+
+	return report, nil
+}
+
+// groupIdleness compares an Empty group's committed offsets with the current watermarks
+func groupIdleness(client sarama.Client, group ConsumerGroupInfo, offsets map[string]map[int32]int64, exists map[string]bool) IdleGroup {
+	idle := IdleGroup{Group: group.Name, State: group.State}
+	if len(offsets) == 0 {
+		idle.Reasons = append(idle.Reasons, IdleNoOffsets)
+		return idle
+	}
+
+	for topic, partitions := range offsets {
+		if !exists[topic] {
+			idle.DeletedTopics = append(idle.DeletedTopics, topic)
+			continue
+		}
+		idle.Topics = append(idle.Topics, topic)
+		for partition, offset := range partitions {
+			low, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+			if err != nil {
+				continue
+			}
+			high, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				continue
+			}
+			if offset < low {
+				idle.ExpiredPartitions++
+				offset = low
+			}
+			if high > offset {
+				idle.Lag += high - offset
+			}
+		}
+	}
+	sort.Strings(idle.Topics)
+	sort.Strings(idle.DeletedTopics)
+
+	if len(idle.DeletedTopics) > 0 {
+		idle.Reasons = append(idle.Reasons, IdleDeletedTopics)
+	}
+	if idle.ExpiredPartitions > 0 {
+		idle.Reasons = append(idle.Reasons, IdleOffsetsExpired)
+	}
+	return idle
+}
+
+// topicWrites reports which topics received messages. Measured produce rates are reused,
+// otherwise the high watermarks are sampled at the start and end of window.
+func topicWrites(client sarama.Client, info *KafkaClusterInfo, window time.Duration) (map[string]bool, time.Duration, error) {
+	writes := make(map[string]bool)
+
+	var unmeasured []TopicInfo
+	var measured time.Duration
+	for _, topic := range info.Topics {
+		if rate := topic.ProduceRate; rate != nil {
+			writes[topic.Name] = rate.MessagesPerSec > 0
+			measured = time.Duration(rate.WindowSeconds * float64(time.Second))
+			continue
+		}
+		unmeasured = append(unmeasured, topic)
+	}
+	if len(unmeasured) == 0 {
+		return writes, measured, nil
+	}
+
+	log.Printf("Sampling high watermarks of %d topics over %s...", len(unmeasured), window)
+	start := time.Now()
+	first, err := sampleHighWatermarks(client, unmeasured)
+	if err != nil {
+		return nil, 0, err
+	}
+	time.Sleep(window)
+	last, err := sampleHighWatermarks(client, unmeasured)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, topic := range unmeasured {
+		for partition, end := range last.offsets[topic.Name] {
+			if begin, ok := first.offsets[topic.Name][partition]; ok && end > begin {
+				writes[topic.Name] = true
+			}
+		}
+	}
+	return writes, time.Since(start), nil
+}
+
+// printIdleReport prints a summary of the idle topics and abandoned groups
+func printIdleReport(report *IdleReport) {
+	var topicCandidates, groupCandidates int
+	for _, t := range report.Topics {
+		if t.DeleteCandidate {
+			topicCandidates++
+		}
+	}
+	for _, g := range report.Groups {
+		if g.DeleteCandidate {
+			groupCandidates++
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Idle Resources (window %s)\n", formatAge(report.WindowSeconds))
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("Idle topics:        %d (%d deletion candidates)\n", len(report.Topics), topicCandidates)
+	fmt.Printf("Abandoned groups:   %d\n", groupCandidates)
+	for _, t := range report.Topics {
+		if t.DeleteCandidate {
+			fmt.Printf("  topic  %-50s %s\n", t.Topic, strings.Join(t.Reasons, ", "))
+		}
+	}
+	for _, g := range report.Groups {
+		fmt.Printf("  group  %-50s %s\n", g.Group, strings.Join(g.Reasons, ", "))
+	}
+	fmt.Println()
+}
+
+// saveIdleReport writes the idle report to a JSON file
+func saveIdleReport(report *IdleReport, filename string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal idle report: %w", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// generateCleanupScript writes a script that deletes the deletion candidates. It runs in dry-run
+// mode unless DRY_RUN=false, and lists the reasons above every command for review.
+func generateCleanupScript(report *IdleReport, filename string, commandConfig string) error {
+	var topics []IdleTopic
+	var groups []IdleGroup
+	for _, t := range report.Topics {
+		if t.DeleteCandidate {
+			topics = append(topics, t)
+		}
+	}
+	for _, g := range report.Groups {
+		if g.DeleteCandidate {
+			groups = append(groups, g)
+		}
+	}
+
+	var script strings.Builder
+	script.WriteString("#!/bin/bash\n")
+	script.WriteString("# Kafka Idle Resource Cleanup Script\n")
+	script.WriteString(fmt.Sprintf("# Generated: %s\n", report.Timestamp))
+	script.WriteString(fmt.Sprintf("# Cluster: %s\n", scriptCommentName(report.Cluster)))
+	script.WriteString(fmt.Sprintf("# Candidates: %d topics, %d consumer groups\n", len(topics), len(groups)))
+	script.WriteString("#\n")
+	script.WriteString("# REVIEW EVERY ENTRY BEFORE RUNNING. Deleting a topic deletes its data.\n")
+	script.WriteString("# Delete or comment out the lines of resources you want to keep.\n")
+	script.WriteString("#\n")
+	script.WriteString("# Usage:\n")
+	script.WriteString(fmt.Sprintf("#   ./%s                  # dry run: print the commands\n", filename))
+	script.WriteString(fmt.Sprintf("#   DRY_RUN=false ./%s    # delete\n", filename))
+	script.WriteString("#\n\n")
+
+	script.WriteString("BOOTSTRAP_SERVERS=" + shellQuote(report.Cluster) + "\n")
+	script.WriteString(commandConfigLines(commandConfig))
+	script.WriteString("DRY_RUN=\"${DRY_RUN:-true}\"\n\n")
+
+	script.WriteString("# Kafka commands (adjust path if needed)\n")
+	script.WriteString("KAFKA_TOPICS=\"kafka-topics.sh\"\n")
+	script.WriteString("KAFKA_CONSUMER_GROUPS=\"kafka-consumer-groups.sh\"\n\n")
+
+	script.WriteString("DELETED=0\n")
+	script.WriteString("FAILED=0\n\n")
+	script.WriteString("run() {\n")
+	script.WriteString("  if [ \"$DRY_RUN\" != \"false\" ]; then\n")
+	script.WriteString("    echo \"  [dry run] $*\"\n")
+	script.WriteString("    return 0\n")
+	script.WriteString("  fi\n")
+	script.WriteString("  if \"$@\"; then\n")
+	script.WriteString("    echo \"  ✓ Deleted\"\n")
+	script.WriteString("    ((DELETED++))\n")
+	script.WriteString("  else\n")
+	script.WriteString("    echo \"  ✗ Failed\"\n")
+	script.WriteString("    ((FAILED++))\n")
+	script.WriteString("  fi\n")
+	script.WriteString("}\n\n")
+
+	for _, t := range topics {
+		script.WriteString(fmt.Sprintf("# Topic %s: %s, %d messages", scriptCommentName(t.Topic), strings.Join(t.Reasons, ", "), t.Messages))
+		if t.SinceLastWriteSeconds > 0 {
+			script.WriteString(fmt.Sprintf(", last write %s ago", formatAge(t.SinceLastWriteSeconds)))
+		}
+		script.WriteString("\n")
+		script.WriteString(fmt.Sprintf("echo \"Deleting topic:\" %s\n", shellQuote(t.Topic)))
+		script.WriteString(fmt.Sprintf("run $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --delete --topic %s\n\n", shellQuote(t.Topic)))
+	}
+
+	for _, g := range groups {
+		script.WriteString(fmt.Sprintf("# Group %s: %s, lag %d", scriptCommentName(g.Group), strings.Join(g.Reasons, ", "), g.Lag))
+		if len(g.DeletedTopics) > 0 {
+			names := make([]string, len(g.DeletedTopics))
+			for i, topic := range g.DeletedTopics {
+				names[i] = scriptCommentName(topic)
+			}
+			script.WriteString(fmt.Sprintf(", deleted topics %s", strings.Join(names, ", ")))
+		}
+		script.WriteString("\n")
+		script.WriteString(fmt.Sprintf("echo \"Deleting consumer group:\" %s\n", shellQuote(g.Group)))
+		script.WriteString(fmt.Sprintf("run $KAFKA_CONSUMER_GROUPS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --delete --group %s\n\n", shellQuote(g.Group)))
+	}
+
+	script.WriteString("echo \"========================================\"\n")
+	script.WriteString("if [ \"$DRY_RUN\" != \"false\" ]; then\n")
+	script.WriteString("  echo \"Dry run: nothing deleted. Run with DRY_RUN=false to delete.\"\n")
+	script.WriteString("else\n")
+	script.WriteString("  echo \"Deleted: $DELETED, failed: $FAILED\"\n")
+	script.WriteString("fi\n")
+	script.WriteString("echo \"========================================\"\n")
+	script.WriteString("[ $FAILED -eq 0 ]\n")
+
+	return os.WriteFile(filename, []byte(script.String()), 0755)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestGenerateCleanupScriptQuotesNames(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "pwned")
+
+	hostile := []string{
+		"$(touch " + marker + ")",
+		"`touch " + marker + "`",
+		"it's \"quoted\" $HOME",
+		"line\ntouch " + marker,
+	}
+	report := &IdleReport{Timestamp: "2024-01-02T03:04:05Z", Cluster: "kafka:9092"}
+	for _, name := range hostile {
+		report.Groups = append(report.Groups, IdleGroup{
+			Group:           name,
+			DeletedTopics:   []string{name},
+			Reasons:         []string{"empty"},
+			DeleteCandidate: true,
+		})
+	}
+	report.Topics = []IdleTopic{{Topic: "orders", Reasons: []string{"no writes"}, DeleteCandidate: true}}
+
+	script := filepath.Join(dir, "cleanup.sh")
+	if err := generateCleanupScript(report, script, ""); err != nil {
+		t.Fatalf("generateCleanupScript: %v", err)
+	}
+
+	// Stand-ins for the Kafka tools record their arguments, NUL-separated
+	args := filepath.Join(dir, "args")
+	for _, tool := range []string{"kafka-topics.sh", "kafka-consumer-groups.sh"} {
+		fake := "#!/bin/bash\nfor a in \"$@\"; do printf '%s\\0' \"$a\" >> " + shellQuote(args) + "; done\n"
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte(fake), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, dryRun := range []string{"true", "false"} {
+		cmd := exec.Command(bash, script)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "DRY_RUN="+dryRun, "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("DRY_RUN=%s: %v\n%s", dryRun, err, out)
+		}
+		if _, err := os.Stat(marker); err == nil {
+			t.Fatalf("DRY_RUN=%s: a group name was executed", dryRun)
+		}
+	}
+
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := strings.Split(string(data), "\x00")
+	for _, name := range hostile {
+		found := false
+		for i, arg := range received {
+			if arg == "--group" && i+1 < len(received) && received[i+1] == name {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("group %q was not passed verbatim", name)
+		}
+	}
+}
+
+func TestFindIdleResourcesCountsFilteredGroups(t *testing.T) {
+	// analytics commits offsets for orders and reporting assigns payments, but both are excluded
+	// by the group filter; audit has no reader at all
+	assignment := binary.BigEndian.AppendUint16(nil, 0)
+	assignment = binary.BigEndian.AppendUint32(assignment, 1)
+	assignment = binary.BigEndian.AppendUint16(assignment, uint16(len("payments")))
+	assignment = append(assignment, "payments"...)
+	assignment = binary.BigEndian.AppendUint32(assignment, 1)
+	assignment = binary.BigEndian.AppendUint32(assignment, 0)
+	assignment = binary.BigEndian.AppendUint32(assignment, 0xffffffff)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	metadata := sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()).SetController(broker.BrokerID())
+	coordinator := sarama.NewMockFindCoordinatorResponse(t)
+	for _, topic := range []string{"orders", "payments", "audit"} {
+		metadata.SetLeader(topic, 0, broker.BrokerID())
+	}
+	for _, group := range []string{"billing", "analytics", "reporting"} {
+		coordinator.SetCoordinator(sarama.CoordinatorGroup, group, broker)
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest":     sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest":        metadata,
+		"FindCoordinatorRequest": coordinator,
+		"ListGroupsRequest": sarama.NewMockListGroupsResponse(t).
+			AddGroup("billing", "consumer").
+			AddGroup("analytics", "consumer").
+			AddGroup("reporting", "consumer"),
+		"DescribeGroupsRequest": sarama.NewMockDescribeGroupsResponse(t).
+			AddGroupDescription("reporting", &sarama.GroupDescription{
+				GroupId:      "reporting",
+				State:        "Stable",
+				ProtocolType: "consumer",
+				Members: map[string]*sarama.GroupMemberDescription{
+					"member-1": {MemberId: "member-1", MemberAssignment: assignment},
+				},
+			}),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("analytics", "orders", 0, 10, "", sarama.ErrNoError),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0 // the ListGroups mock cannot encode v4
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &KafkaClusterInfo{ConsumerGroups: []ConsumerGroupInfo{{Name: "billing", State: "Empty"}}}
+	for _, topic := range []string{"orders", "payments", "audit"} {
+		info.Topics = append(info.Topics, TopicInfo{
+			Name:          topic,
+			TotalMessages: 100,
+			ProduceRate:   &ProduceRate{WindowSeconds: 60},
+		})
+	}
+	groups, err := newNameFilter(nil, []string{"analytics", "reporting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := findIdleResources(client, admin, info, []string{"orders", "payments", "audit"}, IdleOptions{Groups: groups})
+	if err != nil {
+		t.Fatalf("findIdleResources: %v", err)
+	}
+
+	candidates := make(map[string]bool)
+	for _, topic := range report.Topics {
+		if topic.DeleteCandidate {
+			candidates[topic.Topic] = true
+		}
+		noConsumer := false
+		for _, reason := range topic.Reasons {
+			noConsumer = noConsumer || reason == IdleNoConsumer
+		}
+		if noConsumer != (topic.Topic == "audit") {
+			t.Errorf("%s: unexpected reasons %v", topic.Topic, topic.Reasons)
+		}
+	}
+	if len(candidates) != 1 || !candidates["audit"] {
+		t.Errorf("expected only audit as a deletion candidate, got %v", candidates)
+	}
+
+	if len(report.Groups) != 1 || report.Groups[0].Group != "billing" {
+		t.Errorf("expected only billing as an abandoned group, got %+v", report.Groups)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// configureKerberos sets up SASL/GSSAPI authentication from the command line options.
+// Exactly one credential source is used: keytab, credential cache, or password.
+func configureKerberos(config *sarama.Config, opts SecurityOptions) error {
+	principal := opts.SASLUsername
+	realm := opts.KerberosRealm
+
+	// Accept "user@REALM" and take the realm from the principal if not given separately
+	if i := strings.LastIndex(principal, "@"); i >= 0 {
+		if realm == "" {
+			realm = principal[i+1:]
+		}
+		principal = principal[:i]
+	}
+
+	gssapi := sarama.GSSAPIConfig{
+		KerberosConfigPath: opts.KerberosConfigPath,
+		ServiceName:        opts.KerberosServiceName,
+		Username:           principal,
+		Realm:              realm,
+		DisablePAFXFAST:    opts.KerberosDisableFAST,
+	}
+
+	switch {
+	case opts.KerberosKeytab != "":
+		gssapi.AuthType = sarama.KRB5_KEYTAB_AUTH
+		gssapi.KeyTabPath = opts.KerberosKeytab
+	case opts.KerberosCCache != "":
+		gssapi.AuthType = sarama.KRB5_CCACHE_AUTH
+		gssapi.CCachePath = opts.KerberosCCache
+	case opts.SASLPassword != "":
+		gssapi.AuthType = sarama.KRB5_USER_AUTH
+		gssapi.Password = opts.SASLPassword
+	default:
+		return fmt.Errorf("GSSAPI requires -kerberos-keytab, -kerberos-ccache or -sasl-password")
+	}
+
+	if gssapi.AuthType != sarama.KRB5_CCACHE_AUTH && gssapi.Username == "" {
+		return fmt.Errorf("GSSAPI requires the Kerberos principal in -sasl-username")
+	}
+	if gssapi.AuthType != sarama.KRB5_CCACHE_AUTH && gssapi.Realm == "" {
+		return fmt.Errorf("GSSAPI requires -kerberos-realm or a principal in the form user@REALM")
+	}
+	if gssapi.ServiceName == "" {
+		gssapi.ServiceName = "kafka"
+	}
+
+	if err := checkKerberosLogin(&gssapi); err != nil {
+		return err
+	}
+
+	config.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
+	config.Net.SASL.GSSAPI = gssapi
+	return nil
+}
+
+// checkKerberosLogin obtains a TGT before any broker is contacted, so that krb5.conf,
+// keytab and KDC problems are reported directly instead of as broker connection failures
+func checkKerberosLogin(gssapi *sarama.GSSAPIConfig) error {
+	if _, err := os.Stat(gssapi.KerberosConfigPath); err != nil {
+		return fmt.Errorf("cannot read Kerberos configuration %s: %v (set -kerberos-config)", gssapi.KerberosConfigPath, err)
+	}
+	if gssapi.AuthType == sarama.KRB5_KEYTAB_AUTH {
+		if _, err := os.Stat(gssapi.KeyTabPath); err != nil {
+			return fmt.Errorf("cannot read keytab %s: %v", gssapi.KeyTabPath, err)
+		}
+	}
+
+	client, err := sarama.NewKerberosClient(gssapi)
+	if err != nil {
+		return fmt.Errorf("error initializing Kerberos client: %v%s", err, explainKerberosError(err))
+	}
+	defer client.Destroy()
+
+	if err := client.Login(); err != nil {
+		return fmt.Errorf("Kerberos login failed for %s@%s: %v%s", gssapi.Username, gssapi.Realm, err, explainKerberosError(err))
+	}
+
+	log.Printf("Obtained Kerberos ticket for %s@%s (service name: %s)", client.CName().PrincipalNameString(), client.Domain(), gssapi.ServiceName)
+	return nil
+}
+
+// explainKerberosError maps common Kerberos failures to a short explanation of the likely cause
+func explainKerberosError(err error) string {
+	msg := err.Error()
+
+	hints := []struct {
+		match string
+		hint  string
+	}{
+		{"KDC_ERR_C_PRINCIPAL_UNKNOWN", "the principal does not exist in the realm; check -sasl-username and -kerberos-realm (realms are case sensitive)"},
+		{"KDC_ERR_S_PRINCIPAL_UNKNOWN", "the broker service principal is unknown to the KDC; check -kerberos-service-name and that broker addresses resolve to the hostnames in their principals"},
+		{"KDC_ERR_PREAUTH_FAILED", "wrong password or stale keytab (key version number changed after a password reset)"},
+		{"KDC_ERR_ETYPE_NOSUPP", "no common encryption type; check permitted_enctypes in krb5.conf and the keytab entries"},
+		{"KDC_ERR_CLIENT_REVOKED", "the principal is locked or disabled"},
+		{"KRB_AP_ERR_SKEW", "clock skew between this host and the KDC is too large; synchronize time (NTP)"},
+		{"KRB_AP_ERR_MODIFIED", "the service ticket could not be decrypted; the broker keytab or service principal does not match"},
+		{"cannot find KDC", "no KDC is configured for the realm; add it under [realms] in krb5.conf or enable dns_lookup_kdc"},
+		{"Networking_Error", "the KDC could not be reached; check network access to the KDC (port 88)"},
+		{"no keytab entry", "the keytab has no key for this principal; check the principal name and realm with klist -kt"},
+		{"could not get key from keytab", "the keytab has no key for this principal; check the principal name and realm with klist -kt"},
+		{"Error getting Kerberos service ticket", "a TGT was obtained but no service ticket could be issued for the broker"},
+	}
+
+	for _, h := range hints {
+		if strings.Contains(msg, h.match) {
+			return "\nHint: " + h.hint
+		}
+	}
+	return ""
+}
+
+// defaultKerberosConfigPath honors KRB5_CONFIG like the MIT Kerberos tools do
+func defaultKerberosConfigPath() string {
+	if path := os.Getenv("KRB5_CONFIG"); path != "" {
+		return path
+	}
+	return "/etc/krb5.conf"
+}
+
+// kerberosJAASConfig returns the sasl.jaas.config value equivalent to the sarama GSSAPI settings
+func kerberosJAASConfig(gssapi sarama.GSSAPIConfig) string {
+	principal := gssapi.Username
+	if gssapi.Realm != "" {
+		principal += "@" + gssapi.Realm
+	}
+
+	switch gssapi.AuthType {
+	case sarama.KRB5_KEYTAB_AUTH:
+		return fmt.Sprintf("com.sun.security.auth.module.Krb5LoginModule required useKeyTab=true storeKey=true keyTab=\"%s\" principal=\"%s\";",
+			gssapi.KeyTabPath, principal)
+	case sarama.KRB5_CCACHE_AUTH:
+		return fmt.Sprintf("com.sun.security.auth.module.Krb5LoginModule required useTicketCache=true ticketCache=\"%s\";",
+			gssapi.CCachePath)
+	default:
+		// The JVM login module cannot take a password from the JAAS config; it needs a kinit ticket
+		return fmt.Sprintf("com.sun.security.auth.module.Krb5LoginModule required useTicketCache=true principal=\"%s\";", principal)
+	}
+}
+
+// kerberosJavaOpts returns the KAFKA_OPTS needed by the Kafka CLI tools to find krb5.conf
+func kerberosJavaOpts(gssapi sarama.GSSAPIConfig) string {
+	return fmt.Sprintf("-Djava.security.krb5.conf=%s", gssapi.KerberosConfigPath)
+}
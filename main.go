@@ -3,8 +3,6 @@ package main
 import (
 	"crypto/sha256"
 	"crypto/sha512"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -58,6 +56,7 @@ func (x *XDGSCRAMClient) Done() bool {
 type BrokerInfo struct {
 	ID              int32  `json:"id"`
 	Address         string `json:"address"`
+	Rack            string `json:"rack,omitempty"`
 	Version         string `json:"version"`
 	Partitions      int    `json:"partitions"`
 	Leaders         int    `json:"leaders"`
@@ -66,30 +65,50 @@ type BrokerInfo struct {
 
 type TopicInfo struct {
 	Name              string            `json:"name"`
+	TargetName        string            `json:"target_name,omitempty"` // name on the target cluster, from -rename-map
 	Partitions        int               `json:"partitions"`
 	ReplicationFactor int               `json:"replication_factor"`
 	TotalMessages     int64             `json:"total_messages"`
 	Configs           map[string]string `json:"configs,omitempty"`
+	UnderReplicated   int               `json:"under_replicated_partitions,omitempty"`
+	PartitionDetails  []PartitionInfo   `json:"partition_details,omitempty"`
+	Sample            *MessageSample    `json:"sample,omitempty"`
+	Schemas           *TopicSchemas     `json:"schemas,omitempty"`
+	ProduceRate       *ProduceRate      `json:"produce_rate,omitempty"`
+	Skew              *PartitionSkew    `json:"partition_skew,omitempty"`
+	DataAge           *DataAge          `json:"data_age,omitempty"`
+}
+
+// PartitionInfo is the replica assignment of one partition
+type PartitionInfo struct {
+	ID       int32   `json:"id"`
+	Leader   int32   `json:"leader"`
+	Replicas []int32 `json:"replicas"`
+	ISR      []int32 `json:"isr"`
 }
 
 type ConsumerGroupInfo struct {
-	Name    string   `json:"name"`
-	Topics  []string `json:"topics"`
-	Members int      `json:"members"`
-	State   string   `json:"state"`
+	Name         string   `json:"name"`
+	Topics       []string `json:"topics"`
+	Members      int      `json:"members"`
+	State        string   `json:"state"`
+	ProtocolType string   `json:"protocol_type,omitempty"`
 }
 
 type PartitionOffset struct {
-	Partition int   `json:"partition"`
-	Offset    int64 `json:"offset"`
-	Timestamp int64 `json:"timestamp,omitempty"`
-	Lag       int64 `json:"lag,omitempty"`
+	Partition    int   `json:"partition"`
+	Offset       int64 `json:"offset"`
+	Timestamp    int64 `json:"timestamp,omitempty"`
+	LogEndOffset int64 `json:"log_end_offset"` // high watermark at backup time, -1 if unknown
+	Lag          int64 `json:"lag,omitempty"`
 }
 
 type ConsumerGroupOffsets struct {
-	Group     string                       `json:"group"`
-	Topics    map[string][]PartitionOffset `json:"topics"`
-	Timestamp string                       `json:"captured_at"`
+	Group        string                       `json:"group"`
+	State        string                       `json:"state,omitempty"`
+	ProtocolType string                       `json:"protocol_type,omitempty"`
+	Topics       map[string][]PartitionOffset `json:"topics"`
+	Timestamp    string                       `json:"captured_at"`
 }
 
 type ConsumerOffsetsBackup struct {
@@ -109,28 +128,87 @@ type KafkaClusterInfo struct {
 	TotalPartitions     int                 `json:"total_partitions"`
 	TotalMessages       int64               `json:"total_messages"`
 	TotalURPs           int                 `json:"total_under_replicated_partitions"`
+	ACLs                []ACLInfo           `json:"acls,omitempty"`
+	SchemaRegistry      *SchemaRegistryInfo `json:"schema_registry,omitempty"`
 }
 
 func main() {
 	brokers := flag.String("brokers", "localhost:9092", "Kafka broker addresses (comma-separated)")
 	outputJSON := flag.String("output", "kafka-cluster-info.json", "Output JSON file")
 	outputHTML := flag.String("html", "kafka-cluster-report.html", "Output HTML report")
+	reportTemplatePath := flag.String("template", "", "Custom report template file or directory, rendered instead of the built-in HTML report (optional)")
+	templateOutput := flag.String("template-output", "", "Output for -template: file for a single template (default: -html), directory for a template directory (default: current directory)")
 	outputDOT := flag.String("dot", "", "Output DOT file for Graphviz visualization (optional)")
+	outputSVG := flag.String("svg", "", "Output SVG diagram rendered without Graphviz (optional)")
+	dotViews := flag.String("dot-views", "topology", "Views for -dot and -svg, comma-separated: topology, brokers, replicas, prefixes (several views are written to <file>-<view>.<ext>)")
+	outputMermaid := flag.String("mermaid", "", "Output Mermaid flowchart of the topology (optional)")
+	outputD2 := flag.String("d2", "", "Output D2 diagram of the topology (optional)")
+	outputFormat := flag.String("format", "", "Additional export formats, comma-separated: csv, markdown, yaml (file names derived from -output or -topic-sizes-output)")
 	recreateScript := flag.String("recreate-script", "", "Generate shell script to recreate topics (optional)")
+	terraformOutput := flag.String("terraform", "", "Generate Terraform HCL (Mongey/kafka provider) for topics and ACLs, plus import blocks (optional)")
+	collectACLs := flag.Bool("collect-acls", false, "Collect ACLs (included in JSON, Terraform and Strimzi output)")
+	strimziOutput := flag.String("strimzi", "", "Generate Strimzi KafkaTopic/KafkaUser manifests: YAML file, or directory (trailing /) with one file per resource (optional)")
+	strimziNamespace := flag.String("strimzi-namespace", "kafka", "Kubernetes namespace for Strimzi manifests")
+	strimziCluster := flag.String("strimzi-cluster", "my-cluster", "Strimzi Kafka cluster name (strimzi.io/cluster label)")
+	strimziUserAuth := flag.String("strimzi-user-auth", "scram-sha-512", "KafkaUser authentication type (scram-sha-512, tls, tls-external, none)")
 	saveOffsets := flag.String("save-offsets", "", "Save consumer group offsets to JSON file (optional)")
 	restoreOffsetsScript := flag.String("restore-offsets-script", "", "Generate script to restore consumer offsets (requires -save-offsets)")
+	commitHistory := flag.String("commit-history", "", "Decode __consumer_offsets (read-only) for last commit times per group and partition, commit frequency and members, and save the report to this JSON file (optional)")
+	commitHistoryRecords := flag.Int64("commit-history-records", 0, "Read at most the last N records of each __consumer_offsets partition for -commit-history (0 = all)")
+	commitStaleAfter := flag.Duration("commit-stale-after", 7*24*time.Hour, "Report groups without commits for this long as stale in -commit-history (0 = off)")
+	offsetCheck := flag.String("offset-check", "", "Check committed offsets against the partition watermarks and save the report to this JSON file (optional)")
+	offsetCheckBackup := flag.String("offset-check-backup", "", "Check the offsets in this -save-offsets backup, renamed by -rename-map, instead of the committed offsets (requires -offset-check)")
+	topicOverrides := flag.String("topic-overrides", "", "Overrides JSON for -recreate-script, -terraform and -strimzi: replication factor, partitions, config denylist and forced configs (optional)")
+	renameMap := flag.String("rename-map", "", "Topic rename mapping JSON (exact, regex and prefix rules) for recreate and restore scripts and compare-clusters.sh (optional)")
+	scriptDialect := flag.String("script-dialect", DialectKafka, "CLI used by -recreate-script and -restore-offsets-script: kafka, rpk (Redpanda) or confluent (Confluent CLI)")
+	commandConfig := flag.String("command-config", "", "Write Kafka CLI client properties for the current auth/TLS flags to this file and use it in generated scripts (optional)")
 	showVersion := flag.Bool("version", false, "Show version information")
 
 	// Topic size flags
 	topicSizes := flag.Bool("topic-sizes", false, "Calculate and display topic sizes")
 	topicSizesOutput := flag.String("topic-sizes-output", "", "Save topic sizes report to JSON file (optional)")
+	sampleMessages := flag.Int("sample-messages", 0, "Sample the last N records per partition for sizes, headers, compression and payload format (read-only, 0 = off)")
+	sampleMaxBytes := flag.Int64("sample-max-bytes", 64*1024*1024, "Total byte budget for -sample-messages")
+	dataAge := flag.Bool("data-age", false, "Read the first and last record of every partition for data age and time since the last write (read-only)")
+	stalledAfter := flag.Duration("stalled-after", 24*time.Hour, "Flag topics without writes for this long in -data-age (0 = off)")
+	idleReport := flag.String("idle-report", "", "Find idle topics and abandoned consumer groups and save the report to this JSON file (optional)")
+	idleWindow := flag.Duration("idle-window", 5*time.Minute, "High watermark sampling window for -idle-report (measurements from -rate-window are reused)")
+	idleLag := flag.Int64("idle-lag", 1000000, "Empty consumer groups with at least this total lag are reported as abandoned")
+	cleanupScript := flag.String("cleanup-script", "", "Generate a reviewable script deleting the -idle-report candidates (requires -idle-report)")
+	rateWindow := flag.Duration("rate-window", 0, "Measure produce rates by sampling high watermarks over this window, e.g. 60s (0 = off)")
+	rateSamples := flag.Int("rate-samples", 2, "Number of high watermark samples over -rate-window (peak rate uses consecutive samples)")
+	topicSizesInput := flag.String("topic-sizes-input", "", "Topic sizes report JSON (from -topic-sizes-output) used for bytes/sec and partition size skew (optional)")
+	forecastHistory := flag.String("forecast-history", "", "Capacity forecast from topic sizes reports (comma-separated files or globs, at least two)")
+	forecastOutput := flag.String("forecast-output", "kafka-capacity-forecast.json", "Output JSON file for -forecast-history")
+	diskCapacity := flag.String("disk-capacity", "", "Usable disk per broker for -forecast-history, e.g. 2TiB (optional)")
+	skewThreshold := flag.Float64("skew-threshold", 2.0, "Flag topics whose largest partition has at least this multiple of the mean messages or size")
 	topicList := flag.String("topic-list", "", "Comma-separated list of topics to check (optional, default: all topics)")
 
+	// Topic and consumer group filters (all modes)
+	var includeTopics, excludeTopics, includeGroups, excludeGroups patternList
+	flag.Var(&includeTopics, "include-topics", "Only include topics matching these globs (comma-separated, repeatable) or re:<regex>")
+	flag.Var(&excludeTopics, "exclude-topics", "Exclude topics matching these globs (comma-separated, repeatable) or re:<regex>")
+	flag.Var(&includeGroups, "include-groups", "Only include consumer groups matching these globs (comma-separated, repeatable) or re:<regex>")
+	flag.Var(&excludeGroups, "exclude-groups", "Exclude consumer groups matching these globs (comma-separated, repeatable) or re:<regex>")
+	excludeInternal := flag.Bool("exclude-internal", false, "Exclude internal topics (__*) and Schema Registry, Connect, Streams and MirrorMaker 2 internal topics")
+
 	// Authentication flags
 	securityProtocol := flag.String("security-protocol", "", "Security protocol (SASL_SSL, SASL_PLAINTEXT, SSL, or empty for PLAINTEXT)")
-	saslMechanism := flag.String("sasl-mechanism", "PLAIN", "SASL mechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512)")
-	saslUsername := flag.String("sasl-username", "", "SASL username")
-	saslPassword := flag.String("sasl-password", "", "SASL password")
+	saslMechanism := flag.String("sasl-mechanism", "PLAIN", "SASL mechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, GSSAPI, AWS_MSK_IAM)")
+	saslUsername := flag.String("sasl-username", "", "SASL username (Kerberos principal for GSSAPI)")
+	saslPassword := flag.String("sasl-password", "", "SASL password (Kerberos password for GSSAPI without keytab)")
+
+	// Kerberos (SASL/GSSAPI) flags
+	kerberosServiceName := flag.String("kerberos-service-name", "kafka", "Kerberos service name of the brokers (GSSAPI)")
+	kerberosRealm := flag.String("kerberos-realm", "", "Kerberos realm (GSSAPI, default: taken from user@REALM principal)")
+	kerberosConfig := flag.String("kerberos-config", defaultKerberosConfigPath(), "Path to krb5.conf (GSSAPI, default: $KRB5_CONFIG or /etc/krb5.conf)")
+	kerberosKeytab := flag.String("kerberos-keytab", "", "Path to keytab file (GSSAPI keytab authentication)")
+	kerberosCCache := flag.String("kerberos-ccache", "", "Path to credential cache from kinit (GSSAPI ticket cache authentication, e.g. $KRB5CCNAME)")
+	kerberosDisableFAST := flag.Bool("kerberos-disable-fast", false, "Disable PA-FX-FAST (needed for some Active Directory KDCs)")
+
+	// AWS MSK IAM flags
+	awsRegion := flag.String("aws-region", "", "AWS region for MSK IAM (default: $AWS_REGION, profile region or broker hostname)")
+	awsProfile := flag.String("aws-profile", "", "AWS shared config profile for MSK IAM (default: $AWS_PROFILE or default chain)")
 
 	// TLS/SSL flags
 	tlsCACert := flag.String("tls-ca-cert", "", "Path to CA certificate file (for SSL/TLS)")
@@ -138,6 +216,16 @@ func main() {
 	tlsClientKey := flag.String("tls-client-key", "", "Path to client key file (for mTLS)")
 	tlsSkipVerify := flag.Bool("tls-skip-verify", false, "Skip TLS certificate verification (insecure, for development only)")
 
+	// Schema Registry flags
+	schemaRegistryURL := flag.String("schema-registry", "", "Schema Registry URL: map subjects to topics (optional)")
+	schemaRegistryUsername := flag.String("schema-registry-username", "", "Schema Registry basic auth username (API key on Confluent Cloud)")
+	schemaRegistryPassword := flag.String("schema-registry-password", "", "Schema Registry basic auth password (API secret on Confluent Cloud)")
+	schemaRegistryCACert := flag.String("schema-registry-ca-cert", "", "Path to CA certificate file for the Schema Registry (default: system certificates)")
+	schemaRegistryClientCert := flag.String("schema-registry-client-cert", "", "Path to client certificate file for the Schema Registry (mTLS)")
+	schemaRegistryClientKey := flag.String("schema-registry-client-key", "", "Path to client key file for the Schema Registry (mTLS)")
+	schemaRegistrySkipVerify := flag.Bool("schema-registry-skip-verify", false, "Skip Schema Registry TLS certificate verification (insecure)")
+	schemaRegistryExport := flag.String("schema-registry-export", "", "Export all versions of the selected subjects to JSON file (requires -schema-registry)")
+
 	flag.Parse()
 
 	if *showVersion {
@@ -148,6 +236,70 @@ func main() {
 		os.Exit(0)
 	}
 
+	formats, err := parseFormats(*outputFormat)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	views, err := parseViews(*dotViews)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if *schemaRegistryExport != "" && *schemaRegistryURL == "" {
+		log.Fatalf("Error: -schema-registry-export requires -schema-registry")
+	}
+	if *cleanupScript != "" && *idleReport == "" {
+		log.Fatalf("Error: -cleanup-script requires -idle-report")
+	}
+	if *offsetCheckBackup != "" && *offsetCheck == "" {
+		log.Fatalf("Error: -offset-check-backup requires -offset-check")
+	}
+	if *idleReport != "" && *idleWindow <= 0 && *rateWindow <= 0 {
+		log.Fatalf("Error: -idle-report needs -idle-window or -rate-window to detect writes")
+	}
+
+	overrides, err := loadTopicOverrides(*topicOverrides)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	dialect, err := parseScriptDialect(*scriptDialect)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	var renames *TopicRenames
+	if *renameMap != "" {
+		renames, err = loadTopicRenames(*renameMap)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	var sizesHistory []*TopicSizesReport
+	var capacityBytes int64
+	if *forecastHistory != "" {
+		if sizesHistory, err = loadSizesHistory(*forecastHistory); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if *diskCapacity != "" {
+			if capacityBytes, err = parseByteSize(*diskCapacity); err != nil {
+				log.Fatalf("Error: -disk-capacity: %v", err)
+			}
+		}
+	}
+
+	if *excludeInternal {
+		excludeTopics = append(excludeTopics, internalTopicPatterns...)
+	}
+	topicFilter, err := newNameFilter(includeTopics, excludeTopics)
+	if err != nil {
+		log.Fatalf("Error in topic filter: %v", err)
+	}
+	groupFilter, err := newNameFilter(includeGroups, excludeGroups)
+	if err != nil {
+		log.Fatalf("Error in consumer group filter: %v", err)
+	}
+
 	brokerList := strings.Split(*brokers, ",")
 
 	log.Printf("Kafka Analyzer v%s (%s)", Version, GitCommit)
@@ -166,139 +318,89 @@ func main() {
 	config.Metadata.Retry.Max = 3
 	config.Metadata.Retry.Backoff = 250 * time.Millisecond
 
-	// Configure security protocol
-	if *securityProtocol != "" {
-		switch strings.ToUpper(*securityProtocol) {
-		case "SASL_SSL":
-			config.Net.SASL.Enable = true
-			config.Net.TLS.Enable = true
-		case "SASL_PLAINTEXT":
-			config.Net.SASL.Enable = true
-			config.Net.TLS.Enable = false
-		case "SSL":
-			config.Net.TLS.Enable = true
-		default:
-			log.Fatalf("Unknown security protocol: %s", *securityProtocol)
-		}
+	securityOpts := SecurityOptions{
+		Protocol:            *securityProtocol,
+		SASLMechanism:       *saslMechanism,
+		SASLUsername:        *saslUsername,
+		SASLPassword:        *saslPassword,
+		KerberosServiceName: *kerberosServiceName,
+		KerberosRealm:       *kerberosRealm,
+		KerberosConfigPath:  *kerberosConfig,
+		KerberosKeytab:      *kerberosKeytab,
+		KerberosCCache:      *kerberosCCache,
+		KerberosDisableFAST: *kerberosDisableFAST,
+		AWSRegion:           *awsRegion,
+		AWSProfile:          *awsProfile,
+		Brokers:             brokerList,
+		TLSCACert:           *tlsCACert,
+		TLSClientCert:       *tlsClientCert,
+		TLSClientKey:        *tlsClientKey,
+		TLSSkipVerify:       *tlsSkipVerify,
 	}
-
-	// Configure SASL
-	if config.Net.SASL.Enable {
-		if *saslUsername == "" || *saslPassword == "" {
-			log.Fatalf("SASL username and password are required when using SASL authentication")
-		}
-
-		config.Net.SASL.User = *saslUsername
-		config.Net.SASL.Password = *saslPassword
-		// AWS MSK requires SASL handshake version 1
-		config.Net.SASL.Handshake = true
-		config.Net.SASL.Version = 1
-
-		switch strings.ToUpper(*saslMechanism) {
-		case "PLAIN":
-			config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
-		case "SCRAM-SHA-256":
-			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
-			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA256} }
-		case "SCRAM-SHA-512":
-			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
-			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA512} }
-		default:
-			log.Fatalf("Unknown SASL mechanism: %s", *saslMechanism)
-		}
-
-		log.Printf("Using SASL authentication: protocol=%s, mechanism=%s, user=%s", *securityProtocol, *saslMechanism, *saslUsername)
+	if err := configureSecurity(config, securityOpts); err != nil {
+		log.Fatalf("Error configuring security: %v", err)
 	}
 
-	// Configure TLS
-	if config.Net.TLS.Enable {
-		tlsConfig := &tls.Config{
-			InsecureSkipVerify: *tlsSkipVerify,
-		}
-
-		// Load CA certificate if provided
-		if *tlsCACert != "" {
-			caCert, err := os.ReadFile(*tlsCACert)
-			if err != nil {
-				log.Fatalf("Error reading CA certificate: %v", err)
-			}
-			caCertPool := x509.NewCertPool()
-			if !caCertPool.AppendCertsFromPEM(caCert) {
-				log.Fatalf("Failed to parse CA certificate")
-			}
-			tlsConfig.RootCAs = caCertPool
-			log.Printf("Loaded CA certificate from: %s", *tlsCACert)
-		} else if !*tlsSkipVerify {
-			// Use system certificates when no CA cert provided and verification enabled
-			// This is needed for AWS MSK and other managed Kafka services
-			systemCertPool, err := x509.SystemCertPool()
-			if err != nil {
-				log.Printf("Warning: Failed to load system certificates, using empty pool: %v", err)
-				systemCertPool = x509.NewCertPool()
-			}
-			tlsConfig.RootCAs = systemCertPool
-		}
-
-		// Load client certificate and key for mTLS
-		if *tlsClientCert != "" && *tlsClientKey != "" {
-			cert, err := tls.LoadX509KeyPair(*tlsClientCert, *tlsClientKey)
-			if err != nil {
-				log.Fatalf("Error loading client certificate/key: %v", err)
-			}
-			tlsConfig.Certificates = []tls.Certificate{cert}
-			log.Printf("Loaded client certificate for mTLS from: %s", *tlsClientCert)
-		} else if *tlsClientCert != "" || *tlsClientKey != "" {
-			log.Fatalf("Both -tls-client-cert and -tls-client-key must be provided for mTLS")
+	// Write Kafka CLI client properties if requested
+	if *commandConfig != "" {
+		if err := checkScriptPath(*commandConfig); err != nil {
+			log.Fatalf("Error: -command-config %v", err)
 		}
-
-		config.Net.TLS.Config = tlsConfig
-
-		if *tlsSkipVerify {
-			log.Printf("WARNING: TLS certificate verification is disabled (insecure)")
+		log.Printf("Writing Kafka CLI client properties to %s...", *commandConfig)
+		files, err := writeKafkaClientProperties(config, securityOpts, *commandConfig)
+		if err != nil {
+			log.Fatalf("Error writing client properties: %v", err)
 		}
-
-		// Only log TLS auth if not using SASL (where TLS is just the transport layer)
-		if !config.Net.SASL.Enable {
-			authType := "TLS/SSL"
-			if len(tlsConfig.Certificates) > 0 {
-				authType = "mTLS (mutual TLS)"
-			}
-			log.Printf("Using %s authentication", authType)
+		for _, f := range files[:len(files)-1] {
+			log.Printf("  Wrote %s", f)
 		}
 	}
 
 	// Handle topic sizes request (separate mode)
 	if *topicSizes {
-		var topicFilter []string
+		var topicNames []string
 		if *topicList != "" {
-			topicFilter = strings.Split(*topicList, ",")
+			topicNames = strings.Split(*topicList, ",")
 			// Trim whitespace from topic names
-			for i, topic := range topicFilter {
-				topicFilter[i] = strings.TrimSpace(topic)
+			for i, topic := range topicNames {
+				topicNames[i] = strings.TrimSpace(topic)
 			}
 		}
 
-		// Try kafka-log-dirs.sh first (KRaft-compatible)
-		log.Println("Attempting to use kafka-log-dirs.sh for KRaft compatibility...")
-		report, err := getTopicSizesViaCLI(config, brokerList, topicFilter)
-		
-		// If CLI method fails, fall back to Sarama API (works on ZooKeeper-based Kafka)
-		if err != nil {
-			log.Printf("kafka-log-dirs.sh failed (%v), falling back to Sarama API...", err)
-			report, err = getTopicSizes(brokerList, config, topicFilter)
-			if err != nil {
-				log.Fatalf("Error getting topic sizes: %v", err)
+		// Resolve the topic filters first, so only the selected topics are described
+		if topicFilter != nil {
+			if len(topicNames) == 0 {
+				if topicNames, err = listTopicNames(brokerList, config); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
 			}
+			topicNames = topicFilter.filterNames(topicNames)
+			if len(topicNames) == 0 {
+				log.Fatalf("Error: No topics match the topic filters")
+			}
+			log.Printf("Selected %d topics after filtering", len(topicNames))
+		}
+
+		report, err := collectTopicSizes(config, securityOpts, brokerList, topicNames)
+		if err != nil {
+			log.Fatalf("Error getting topic sizes: %v", err)
 		}
 
 		// Print report to console
 		printTopicSizes(report)
 
 		// Save to file if requested
+		sizesBase := "kafka-topic-sizes.json"
 		if *topicSizesOutput != "" {
+			sizesBase = *topicSizesOutput
 			if err := saveTopicSizesJSON(report, *topicSizesOutput); err != nil {
 				log.Fatalf("Error saving report: %v", err)
 			}
+			formats = removeFormat(formats, FormatJSON)
+		}
+
+		if err := writeTopicSizesExports(report, sizesBase, formats); err != nil {
+			log.Fatalf("Error exporting topic sizes: %v", err)
 		}
 
 		// Exit after topic sizes - this is a separate mode
@@ -307,7 +409,7 @@ func main() {
 
 	admin, err := sarama.NewClusterAdmin(brokerList, config)
 	if err != nil {
-		log.Fatalf("Error creating cluster admin: %v", err)
+		log.Fatalf("Error creating cluster admin: %v%s", err, authErrorHint(config, err))
 	}
 	defer admin.Close()
 
@@ -329,7 +431,8 @@ func main() {
 	broker.Open(config)
 	defer broker.Close()
 
-	metadataReq := &sarama.MetadataRequest{}
+	// Version 1 is the oldest metadata version that includes broker racks
+	metadataReq := &sarama.MetadataRequest{Version: 1}
 	metadata, err := broker.GetMetadata(metadataReq)
 	if err != nil {
 		log.Printf("Warning: Could not fetch metadata: %v", err)
@@ -340,6 +443,7 @@ func main() {
 			brokerInfo := BrokerInfo{
 				ID:      b.ID(),
 				Address: b.Addr(),
+				Rack:    b.Rack(),
 				Version: "", // Will be populated later
 			}
 			brokerDetails = append(brokerDetails, brokerInfo)
@@ -354,6 +458,34 @@ func main() {
 		log.Fatalf("Error listing topics: %v", err)
 	}
 
+	allTopics := make([]string, 0, len(topics))
+	for name := range topics {
+		allTopics = append(allTopics, name)
+	}
+
+	// Apply filters before the per-topic requests below
+	if topicFilter != nil {
+		for name := range topics {
+			if !topicFilter.Match(name) {
+				delete(topics, name)
+			}
+		}
+		log.Printf("Selected %d topics after filtering", len(topics))
+	}
+
+	// Per-partition sizes for the skew analysis, from an earlier topic sizes report
+	var sizesReport *TopicSizesReport
+	partitionSizes := make(map[string]map[int32]int64)
+	if *topicSizesInput != "" {
+		sizesReport, err = loadTopicSizesReport(*topicSizesInput)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		for _, topic := range sizesReport.Topics {
+			partitionSizes[topic.Topic] = topic.PartitionSizes
+		}
+	}
+
 	topicInfos := make([]TopicInfo, 0, len(topics))
 	for name, detail := range topics {
 		topicInfo := TopicInfo{
@@ -377,7 +509,9 @@ func main() {
 		}
 
 		// Get high watermarks (total messages) for all partitions
-		topicInfo.TotalMessages = getTopicMessageCount(client, name, int(detail.NumPartitions))
+		var partitionCounts map[int32]int64
+		topicInfo.TotalMessages, partitionCounts = getTopicMessageCount(client, name, int(detail.NumPartitions))
+		topicInfo.Skew = partitionSkew(partitionCounts, partitionSizes[name], *skewThreshold)
 
 		topicInfos = append(topicInfos, topicInfo)
 	}
@@ -386,17 +520,109 @@ func main() {
 		return topicInfos[i].Name < topicInfos[j].Name
 	})
 
+	// Target names for migrations that rename topics
+	if renames != nil {
+		names := make([]string, 0, len(topicInfos))
+		for i := range topicInfos {
+			names = append(names, topicInfos[i].Name)
+			if target := renames.Rename(topicInfos[i].Name); target != topicInfos[i].Name {
+				topicInfos[i].TargetName = target
+			}
+		}
+		if err := renames.validate(names); err != nil {
+			log.Fatalf("Error in rename map %s: %v", *renameMap, err)
+		}
+	}
+
 	clusterInfo.Topics = topicInfos
 	clusterInfo.TotalTopics = len(topicInfos)
 	clusterInfo.TotalPartitions = getTotalPartitions(topicInfos)
 	clusterInfo.TotalMessages = getTotalMessages(topicInfos)
 
+	// Sample records if requested
+	if *sampleMessages > 0 {
+		log.Printf("Sampling last %d records per partition (budget %s)...", *sampleMessages, formatBytes(*sampleMaxBytes))
+		sampleTopics(client, &clusterInfo, *sampleMessages, *sampleMaxBytes)
+	}
+
+	// Broker retention defaults for topics without overrides
+	defaults := kafkaRetentionDefaults
+	if (sizesHistory != nil || *dataAge) && len(clusterInfo.BrokerDetails) > 0 {
+		defaults = fetchRetentionDefaults(admin, clusterInfo.BrokerDetails[0].ID)
+	}
+
+	// Read oldest and newest record timestamps if requested
+	if *dataAge {
+		log.Println("Reading oldest and newest records for data age...")
+		measureDataAge(client, &clusterInfo, defaults, *stalledAfter, time.Now())
+		findings := countAgeFindings(clusterInfo.Topics)
+		log.Printf("Data age: %d topics beyond retention, %d stalled, %d with future timestamps",
+			findings[AgeBeyondRetention], findings[AgeStalled], findings[AgeFutureTimestamps])
+	}
+
+	// Measure produce rates if requested
+	if *rateWindow > 0 {
+		log.Printf("Measuring produce rates over %s (%d samples)...", *rateWindow, *rateSamples)
+		if err := measureProduceRates(client, &clusterInfo, *rateWindow, *rateSamples, sizesReport); err != nil {
+			log.Printf("Warning: Could not measure produce rates: %v", err)
+		}
+	}
+
+	// Forecast disk usage if requested
+	if sizesHistory != nil {
+		for i := range sizesHistory {
+			sizesHistory[i] = filterTopicSizes(sizesHistory[i], topicFilter)
+		}
+
+		log.Printf("Forecasting capacity from %d topic sizes reports...", len(sizesHistory))
+		forecast := buildCapacityForecast(&clusterInfo, sizesHistory, defaults, capacityBytes, time.Now())
+		printCapacityForecast(forecast)
+		if err := saveCapacityForecast(forecast, *forecastOutput); err != nil {
+			log.Fatalf("Error saving capacity forecast: %v", err)
+		}
+		log.Printf("Saved capacity forecast to %s", *forecastOutput)
+	}
+
+	// Map Schema Registry subjects to topics if requested
+	if *schemaRegistryURL != "" {
+		registry, err := newSchemaRegistryClient(SchemaRegistryOptions{
+			URL:           *schemaRegistryURL,
+			Username:      *schemaRegistryUsername,
+			Password:      *schemaRegistryPassword,
+			TLSCACert:     *schemaRegistryCACert,
+			TLSClientCert: *schemaRegistryClientCert,
+			TLSClientKey:  *schemaRegistryClientKey,
+			TLSSkipVerify: *schemaRegistrySkipVerify,
+		})
+		if err != nil {
+			log.Fatalf("Error configuring Schema Registry client: %v", err)
+		}
+
+		log.Printf("Fetching subjects from Schema Registry %s...", registry.baseURL)
+		subjects, err := collectSchemas(registry, &clusterInfo, allTopics, topicFilter)
+		if err != nil {
+			log.Fatalf("Error querying Schema Registry: %v", err)
+		}
+		sr := clusterInfo.SchemaRegistry
+		log.Printf("Found %d subjects: %d topics without schema, %d orphaned subjects, %d not matched to a topic",
+			sr.Subjects, len(sr.TopicsWithoutSchema), len(sr.OrphanedSubjects), len(sr.UnmatchedSubjects))
+
+		if *schemaRegistryExport != "" {
+			log.Printf("Exporting schemas to %s...", *schemaRegistryExport)
+			if err := exportSchemas(registry, subjects, sr.Compatibility, *schemaRegistryExport); err != nil {
+				log.Fatalf("Error exporting schemas: %v", err)
+			}
+		}
+	}
+
 	// Calculate partition and leader distribution across brokers
 	if len(clusterInfo.BrokerDetails) > 0 {
 		log.Println("Calculating broker metrics...")
 		brokerPartitions := make(map[int32]int)
 		brokerLeaders := make(map[int32]int)
 		brokerURPs := make(map[int32]int)
+		topicURPs := make(map[string]int)
+		topicPartitions := make(map[string][]PartitionInfo)
 
 		// Get partition metadata for all topics
 		for topicName := range topics {
@@ -407,6 +633,13 @@ func main() {
 
 			for _, topicMeta := range partitions {
 				for _, partition := range topicMeta.Partitions {
+					topicPartitions[topicName] = append(topicPartitions[topicName], PartitionInfo{
+						ID:       partition.ID,
+						Leader:   partition.Leader,
+						Replicas: partition.Replicas,
+						ISR:      partition.Isr,
+					})
+
 					// Count partition per broker (replicas)
 					for _, replica := range partition.Replicas {
 						brokerPartitions[replica]++
@@ -422,12 +655,20 @@ func main() {
 						for _, replica := range partition.Replicas {
 							brokerURPs[replica]++
 						}
+						topicURPs[topicName]++
 						clusterInfo.TotalURPs++
 					}
 				}
 			}
 		}
 
+		for i := range clusterInfo.Topics {
+			clusterInfo.Topics[i].UnderReplicated = topicURPs[clusterInfo.Topics[i].Name]
+			partitions := topicPartitions[clusterInfo.Topics[i].Name]
+			sort.Slice(partitions, func(a, b int) bool { return partitions[a].ID < partitions[b].ID })
+			clusterInfo.Topics[i].PartitionDetails = partitions
+		}
+
 		// Get broker versions
 		for i := range clusterInfo.BrokerDetails {
 			brokerID := clusterInfo.BrokerDetails[i].ID
@@ -463,6 +704,10 @@ func main() {
 
 	consumerGroups := make([]ConsumerGroupInfo, 0, len(groups))
 	for groupName := range groups {
+		if !groupFilter.Match(groupName) {
+			continue
+		}
+
 		groupInfo := ConsumerGroupInfo{
 			Name: groupName,
 		}
@@ -472,6 +717,7 @@ func main() {
 		if err == nil && len(descriptions) > 0 {
 			desc := descriptions[0]
 			groupInfo.State = desc.State
+			groupInfo.ProtocolType = desc.ProtocolType
 			groupInfo.Members = len(desc.Members)
 
 			// Get topics for this consumer group
@@ -485,8 +731,11 @@ func main() {
 				}
 			}
 
+			// Only subscriptions to selected topics, so edges and offset backups follow the topic filter
 			for topic := range topicMap {
-				groupInfo.Topics = append(groupInfo.Topics, topic)
+				if topicFilter.Match(topic) {
+					groupInfo.Topics = append(groupInfo.Topics, topic)
+				}
 			}
 			sort.Strings(groupInfo.Topics)
 		}
@@ -494,6 +743,10 @@ func main() {
 		consumerGroups = append(consumerGroups, groupInfo)
 	}
 
+	if groupFilter != nil {
+		log.Printf("Selected %d consumer groups after filtering", len(consumerGroups))
+	}
+
 	sort.Slice(consumerGroups, func(i, j int) bool {
 		return consumerGroups[i].Name < consumerGroups[j].Name
 	})
@@ -501,6 +754,58 @@ func main() {
 	clusterInfo.ConsumerGroups = consumerGroups
 	clusterInfo.TotalConsumerGroups = len(consumerGroups)
 
+	// Find idle topics and abandoned consumer groups if requested
+	if *idleReport != "" {
+		log.Println("Looking for idle topics and abandoned consumer groups...")
+		report, err := findIdleResources(client, admin, &clusterInfo, allTopics, IdleOptions{
+			Window:  *idleWindow,
+			MaxLag:  *idleLag,
+			Groups:  groupFilter,
+			Cluster: strings.Join(brokerList, ","),
+		})
+		if err != nil {
+			log.Fatalf("Error finding idle resources: %v", err)
+		}
+		printIdleReport(report)
+		if err := saveIdleReport(report, *idleReport); err != nil {
+			log.Fatalf("Error saving idle report: %v", err)
+		}
+		log.Printf("Saved idle report to %s", *idleReport)
+
+		if *cleanupScript != "" {
+			log.Printf("Generating cleanup script to %s...", *cleanupScript)
+			if err := generateCleanupScript(report, *cleanupScript, *commandConfig); err != nil {
+				log.Fatalf("Error generating cleanup script: %v", err)
+			}
+		}
+	}
+
+	// Decode the commit history from __consumer_offsets if requested
+	if *commitHistory != "" {
+		log.Printf("Reading commit history from %s...", consumerOffsetsTopic)
+		report, err := readCommitHistory(client, CommitHistoryOptions{
+			MaxRecords: *commitHistoryRecords,
+			StaleAfter: *commitStaleAfter,
+			Groups:     groupFilter,
+			Topics:     topicFilter,
+			Cluster:    strings.Join(brokerList, ","),
+		})
+		if err != nil {
+			log.Fatalf("Error reading commit history: %v", err)
+		}
+		printCommitHistory(report)
+		if err := saveCommitHistory(report, *commitHistory); err != nil {
+			log.Fatalf("Error saving commit history: %v", err)
+		}
+		log.Printf("Saved commit history to %s", *commitHistory)
+	}
+
+	// Get ACLs
+	if *collectACLs {
+		log.Println("Fetching ACLs...")
+		clusterInfo.ACLs = fetchACLs(admin)
+	}
+
 	// Write JSON output
 	log.Printf("Writing JSON to %s...", *outputJSON)
 	jsonData, err := json.MarshalIndent(clusterInfo, "", "  ")
@@ -512,33 +817,145 @@ func main() {
 		log.Fatalf("Error writing JSON file: %v", err)
 	}
 
-	// Generate HTML report
-	log.Printf("Generating HTML report to %s...", *outputHTML)
-	if err := generateHTMLReport(&clusterInfo, *outputHTML); err != nil {
-		log.Fatalf("Error generating HTML report: %v", err)
+	// Write additional export formats. The sizes CSV comes from -topic-sizes-input, or is
+	// collected for the selected topics.
+	exportSizes := sizesReport
+	if exportSizes != nil {
+		exportSizes = filterTopicSizes(exportSizes, topicFilter)
+	} else if hasFormat(formats, FormatCSV) && len(topics) > 0 {
+		names := make([]string, 0, len(topics))
+		for name := range topics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if exportSizes, err = collectTopicSizes(config, securityOpts, brokerList, names); err != nil {
+			log.Printf("Warning: Could not get topic sizes, skipping the sizes CSV: %v", err)
+			exportSizes = nil
+		}
+	}
+	if err := writeClusterExports(&clusterInfo, exportSizes, *outputJSON, formats); err != nil {
+		log.Fatalf("Error writing exports: %v", err)
+	}
+
+	// Generate HTML report, or the custom templates replacing it
+	if *reportTemplatePath != "" {
+		output := *templateOutput
+		if output == "" {
+			output = *outputHTML
+			if stat, err := os.Stat(*reportTemplatePath); err == nil && stat.IsDir() {
+				output = "."
+			}
+		}
+		log.Printf("Rendering templates from %s...", *reportTemplatePath)
+		files, err := renderCustomTemplates(&clusterInfo, *reportTemplatePath, output)
+		if err != nil {
+			log.Fatalf("Error rendering templates: %v", err)
+		}
+		for _, f := range files {
+			log.Printf("Wrote %s", f)
+		}
+	} else {
+		log.Printf("Generating HTML report to %s...", *outputHTML)
+		if err := generateHTMLReport(&clusterInfo, *outputHTML); err != nil {
+			log.Fatalf("Error generating HTML report: %v", err)
+		}
 	}
 
 	// Generate DOT file if requested
 	if *outputDOT != "" {
-		log.Printf("Generating DOT file to %s...", *outputDOT)
-		if err := generateDOTFile(&clusterInfo, *outputDOT); err != nil {
+		log.Printf("Generating DOT views (%s) to %s...", strings.Join(views, ", "), *outputDOT)
+		files, err := generateDOTFiles(&clusterInfo, *outputDOT, views)
+		if err != nil {
 			log.Fatalf("Error generating DOT file: %v", err)
 		}
+		if len(files) > 1 {
+			for _, f := range files {
+				log.Printf("Wrote %s", f)
+			}
+		}
+	}
+
+	// Render SVG diagrams if requested
+	if *outputSVG != "" {
+		log.Printf("Rendering SVG views (%s) to %s...", strings.Join(views, ", "), *outputSVG)
+		files, err := generateSVGFiles(&clusterInfo, *outputSVG, views)
+		if err != nil {
+			log.Fatalf("Error generating SVG file: %v", err)
+		}
+		if len(files) > 1 {
+			for _, f := range files {
+				log.Printf("Wrote %s", f)
+			}
+		}
+	}
+
+	// Generate Mermaid and D2 diagrams if requested
+	if *outputMermaid != "" {
+		log.Printf("Generating Mermaid diagram to %s...", *outputMermaid)
+		if err := generateMermaidFile(&clusterInfo, *outputMermaid); err != nil {
+			log.Fatalf("Error generating Mermaid diagram: %v", err)
+		}
+	}
+	if *outputD2 != "" {
+		log.Printf("Generating D2 diagram to %s...", *outputD2)
+		if err := generateD2File(&clusterInfo, *outputD2); err != nil {
+			log.Fatalf("Error generating D2 diagram: %v", err)
+		}
 	}
 
 	// Generate recreation script if requested
 	if *recreateScript != "" {
 		log.Printf("Generating topic recreation script to %s...", *recreateScript)
-		if err := generateRecreateScript(&clusterInfo, *recreateScript); err != nil {
+		if err := generateRecreateScript(&clusterInfo, *recreateScript, RecreateOptions{
+			CommandConfig: *commandConfig,
+			Renames:       renames,
+			Overrides:     overrides,
+			Dialect:       dialect,
+		}); err != nil {
 			log.Fatalf("Error generating recreation script: %v", err)
 		}
 	}
 
+	// The Terraform and Strimzi exports also adopt the existing topics, so their configs are only
+	// changed when overrides are given
+	var exportOverrides *TopicOverrides
+	if *topicOverrides != "" {
+		exportOverrides = overrides
+	}
+
+	// Generate Terraform HCL if requested
+	if *terraformOutput != "" {
+		log.Printf("Generating Terraform HCL to %s...", *terraformOutput)
+		importsFile, err := generateTerraformFiles(&clusterInfo, *terraformOutput, exportOverrides)
+		if err != nil {
+			log.Fatalf("Error generating Terraform files: %v", err)
+		}
+		log.Printf("Wrote Terraform import blocks to %s", importsFile)
+	}
+
+	// Generate Strimzi manifests if requested
+	if *strimziOutput != "" {
+		log.Printf("Generating Strimzi manifests to %s...", *strimziOutput)
+		count, skipped, err := generateStrimziManifests(&clusterInfo, *strimziOutput, StrimziOptions{
+			Namespace: *strimziNamespace,
+			Cluster:   *strimziCluster,
+			UserAuth:  *strimziUserAuth,
+			Overrides: exportOverrides,
+		})
+		if err != nil {
+			log.Fatalf("Error generating Strimzi manifests: %v", err)
+		}
+		log.Printf("Wrote %d Strimzi resources", count)
+		if len(skipped) > 0 {
+			log.Printf("Warning: %d ACLs not converted to KafkaUser (non-User principals, unsupported resources or pattern types)", len(skipped))
+		}
+	}
+
 	// Save consumer group offsets if requested
 	var offsetsBackup *ConsumerOffsetsBackup
 	if *saveOffsets != "" || *restoreOffsetsScript != "" {
 		log.Println("Fetching consumer group offsets...")
-		offsetsBackup, err = fetchConsumerOffsets(admin, consumerGroups, brokerList[0])
+		offsetsBackup, err = fetchConsumerOffsets(admin, client, consumerGroups, topicFilter, brokerList[0])
 		if err != nil {
 			log.Fatalf("Error fetching consumer offsets: %v", err)
 		}
@@ -552,12 +969,50 @@ func main() {
 
 		if *restoreOffsetsScript != "" {
 			log.Printf("Generating offset restore script to %s...", *restoreOffsetsScript)
-			if err := generateRestoreOffsetsScript(offsetsBackup, *restoreOffsetsScript); err != nil {
+			if err := generateRestoreOffsetsScript(offsetsBackup, *restoreOffsetsScript, RestoreOptions{
+				CommandConfig: *commandConfig,
+				Renames:       renames,
+				Dialect:       dialect,
+			}); err != nil {
 				log.Fatalf("Error generating restore script: %v", err)
 			}
 		}
 	}
 
+	// Check committed offsets against the watermarks if requested
+	if *offsetCheck != "" {
+		checked := offsetsBackup
+		source := "committed"
+		var checkRenames *TopicRenames
+		if *offsetCheckBackup != "" {
+			log.Printf("Loading offsets backup %s...", *offsetCheckBackup)
+			checked, err = loadConsumerOffsets(*offsetCheckBackup)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			source = *offsetCheckBackup
+			checkRenames = renames
+		} else if checked == nil {
+			log.Println("Fetching consumer group offsets...")
+			checked, err = fetchConsumerOffsets(admin, client, consumerGroups, topicFilter, brokerList[0])
+			if err != nil {
+				log.Fatalf("Error fetching consumer offsets: %v", err)
+			}
+		}
+
+		log.Println("Checking committed offsets against partition watermarks...")
+		report := checkOffsets(client, checked, checkRenames, allTopics, strings.Join(brokerList, ","))
+		report.Source = source
+		if *offsetCheckBackup != "" {
+			report.BackupTimestamp = checked.Timestamp
+		}
+		printOffsetCheck(report)
+		if err := saveOffsetCheck(report, *offsetCheck); err != nil {
+			log.Fatalf("Error saving offset check: %v", err)
+		}
+		log.Printf("Saved offset check to %s", *offsetCheck)
+	}
+
 	log.Println("Done!")
 	log.Printf("Summary:")
 	log.Printf("  Total Brokers: %d", len(clusterInfo.BrokerDetails))
@@ -570,317 +1025,6 @@ func main() {
 	}
 }
 
-func generateHTMLReport(info *KafkaClusterInfo, filename string) error {
-	html := fmt.Sprintf(`<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <title>Kafka Cluster Report</title>
-    <style>
-        * {
-            margin: 0;
-            padding: 0;
-            box-sizing: border-box;
-        }
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
-            background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%);
-            min-height: 100vh;
-            padding: 20px;
-        }
-        .container {
-            max-width: 1400px;
-            margin: 0 auto;
-            background: white;
-            border-radius: 12px;
-            box-shadow: 0 20px 60px rgba(0,0,0,0.3);
-            overflow: hidden;
-        }
-        .header {
-            background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%);
-            color: white;
-            padding: 40px;
-            text-align: center;
-        }
-        .header h1 {
-            font-size: 2.5em;
-            margin-bottom: 10px;
-        }
-        .header p {
-            font-size: 1.1em;
-            opacity: 0.9;
-        }
-        .stats {
-            display: grid;
-            grid-template-columns: repeat(auto-fit, minmax(250px, 1fr));
-            gap: 20px;
-            padding: 40px;
-            background: #f8f9fa;
-        }
-        .stat-card {
-            background: white;
-            padding: 25px;
-            border-radius: 8px;
-            box-shadow: 0 2px 8px rgba(0,0,0,0.1);
-            text-align: center;
-            transition: transform 0.2s;
-        }
-        .stat-card:hover {
-            transform: translateY(-5px);
-            box-shadow: 0 4px 12px rgba(0,0,0,0.15);
-        }
-        .stat-number {
-            font-size: 3em;
-            font-weight: bold;
-            color: #667eea;
-            margin-bottom: 10px;
-        }
-        .stat-label {
-            color: #666;
-            font-size: 1.1em;
-        }
-        .content {
-            padding: 40px;
-        }
-        .section {
-            margin-bottom: 40px;
-        }
-        .section-title {
-            font-size: 1.8em;
-            color: #333;
-            margin-bottom: 20px;
-            padding-bottom: 10px;
-            border-bottom: 3px solid #667eea;
-        }
-        .chart-container {
-            background: white;
-            border-radius: 8px;
-            padding: 20px;
-            margin-bottom: 30px;
-            box-shadow: 0 2px 8px rgba(0,0,0,0.1);
-        }
-        table {
-            width: 100%%;
-            border-collapse: collapse;
-            background: white;
-            border-radius: 8px;
-            overflow: hidden;
-            box-shadow: 0 2px 8px rgba(0,0,0,0.1);
-        }
-        th {
-            background: #667eea;
-            color: white;
-            padding: 15px;
-            text-align: left;
-            font-weight: 600;
-        }
-        td {
-            padding: 12px 15px;
-            border-bottom: 1px solid #eee;
-        }
-        tr:hover {
-            background: #f8f9fa;
-        }
-        .topic-name {
-            font-weight: 600;
-            color: #667eea;
-        }
-        .badge {
-            display: inline-block;
-            padding: 4px 12px;
-            border-radius: 12px;
-            font-size: 0.85em;
-            font-weight: 600;
-            margin: 2px;
-        }
-        .badge-success {
-            background: #d4edda;
-            color: #155724;
-        }
-        .badge-info {
-            background: #d1ecf1;
-            color: #0c5460;
-        }
-        .badge-warning {
-            background: #fff3cd;
-            color: #856404;
-        }
-        .config-details {
-            font-size: 0.9em;
-            color: #666;
-            margin-top: 5px;
-        }
-        .broker-list {
-            background: #f8f9fa;
-            padding: 15px;
-            border-radius: 6px;
-            margin-bottom: 20px;
-        }
-        .broker-item {
-            display: inline-block;
-            background: white;
-            padding: 8px 15px;
-            border-radius: 6px;
-            margin: 5px;
-            box-shadow: 0 1px 3px rgba(0,0,0,0.1);
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>📊 Kafka Cluster Analysis</h1>
-            <p>Generated on %s</p>
-        </div>
-
-        <div class="stats">
-            <div class="stat-card">
-                <div class="stat-number">%d</div>
-                <div class="stat-label">Brokers</div>
-            </div>
-            <div class="stat-card">
-                <div class="stat-number">%d</div>
-                <div class="stat-label">Topics</div>
-            </div>
-            <div class="stat-card">
-                <div class="stat-number">%d</div>
-                <div class="stat-label">Total Partitions</div>
-            </div>
-            <div class="stat-card">
-                <div class="stat-number">%s</div>
-                <div class="stat-label">Total Messages</div>
-            </div>
-            <div class="stat-card">
-                <div class="stat-number">%d</div>
-                <div class="stat-label">Consumer Groups</div>
-            </div>
-%s        </div>
-
-        <div class="content">
-            <div class="section">
-                <h2 class="section-title">🖥️ Kafka Brokers</h2>
-                <table>
-                    <thead>
-                        <tr>
-                            <th>Broker ID</th>
-                            <th>Address</th>
-                            <th>Version</th>
-                            <th>Partitions</th>
-                            <th>Leaders</th>
-                            <th>Under-Replicated</th>
-                        </tr>
-                    </thead>
-                    <tbody>
-`, info.Timestamp, len(info.BrokerDetails), info.TotalTopics, info.TotalPartitions, formatNumber(info.TotalMessages), info.TotalConsumerGroups, getURPCard(info.TotalURPs))
-
-	for _, broker := range info.BrokerDetails {
-		urpBadge := "badge-success"
-		if broker.UnderReplicated > 0 {
-			urpBadge = "badge-warning"
-		}
-		html += fmt.Sprintf(`                        <tr>
-                            <td><span class="badge badge-info">%d</span></td>
-                            <td>%s</td>
-                            <td><span class="badge badge-success">%s</span></td>
-                            <td><span class="badge badge-info">%d</span></td>
-                            <td><span class="badge badge-info">%d</span></td>
-                            <td><span class="badge %s">%d</span></td>
-                        </tr>
-`, broker.ID, broker.Address, broker.Version, broker.Partitions, broker.Leaders, urpBadge, broker.UnderReplicated)
-	}
-
-	html += `                    </tbody>
-                </table>
-            </div>
-
-
-            <div class="section">
-                <h2 class="section-title">📂 Topics Overview</h2>
-                <table>
-                    <thead>
-                        <tr>
-                            <th>Topic Name</th>
-                            <th>Partitions</th>
-                            <th>Replication Factor</th>
-                            <th>Total Messages</th>
-                            <th>Custom Configurations</th>
-                        </tr>
-                    </thead>
-                    <tbody>
-`
-
-	for _, topic := range info.Topics {
-		configStr := ""
-		if len(topic.Configs) > 0 {
-			configs := make([]string, 0, len(topic.Configs))
-			for k, v := range topic.Configs {
-				configs = append(configs, fmt.Sprintf("%s=%s", k, v))
-			}
-			sort.Strings(configs)
-			configStr = strings.Join(configs, ", ")
-		} else {
-			configStr = "<em>Default</em>"
-		}
-
-		html += fmt.Sprintf(`                        <tr>
-                            <td class="topic-name">%s</td>
-                            <td><span class="badge badge-info">%d</span></td>
-                            <td><span class="badge badge-success">%d</span></td>
-                            <td><span class="badge badge-info">%s</span></td>
-                            <td class="config-details">%s</td>
-                        </tr>
-`, topic.Name, topic.Partitions, topic.ReplicationFactor, formatNumber(topic.TotalMessages), configStr)
-	}
-
-	html += `                    </tbody>
-                </table>
-            </div>
-
-            <div class="section">
-                <h2 class="section-title">👥 Consumer Groups</h2>
-                <table>
-                    <thead>
-                        <tr>
-                            <th>Group Name</th>
-                            <th>State</th>
-                            <th>Members</th>
-                            <th>Subscribed Topics</th>
-                        </tr>
-                    </thead>
-                    <tbody>
-`
-
-	for _, group := range info.ConsumerGroups {
-		topicsStr := strings.Join(group.Topics, ", ")
-		if topicsStr == "" {
-			topicsStr = "<em>None</em>"
-		}
-
-		stateBadge := "badge-success"
-		if group.State != "Stable" {
-			stateBadge = "badge-warning"
-		}
-
-		html += fmt.Sprintf(`                        <tr>
-                            <td class="topic-name">%s</td>
-                            <td><span class="badge %s">%s</span></td>
-                            <td><span class="badge badge-info">%d</span></td>
-                            <td class="config-details">%s</td>
-                        </tr>
-`, group.Name, stateBadge, group.State, group.Members, topicsStr)
-	}
-
-	html += `                    </tbody>
-                </table>
-            </div>
-        </div>
-    </div>
-</body>
-</html>`
-
-	return os.WriteFile(filename, []byte(html), 0644)
-}
-
 func getURPCard(urps int) string {
 	if urps > 0 {
 		return fmt.Sprintf(`            <div class="stat-card" style="border: 2px solid #f44336;">
@@ -937,8 +1081,10 @@ func formatNumber(n int64) string {
 	return result
 }
 
-func getTopicMessageCount(client sarama.Client, topic string, partitions int) int64 {
+// getTopicMessageCount returns the retained messages of a topic, in total and per partition
+func getTopicMessageCount(client sarama.Client, topic string, partitions int) (int64, map[int32]int64) {
 	var total int64
+	counts := make(map[int32]int64, partitions)
 
 	for partition := 0; partition < partitions; partition++ {
 		// Get high watermark (newest offset) - latest offset
@@ -957,83 +1103,11 @@ func getTopicMessageCount(client sarama.Client, topic string, partitions int) in
 
 		// Actual messages = newest - oldest (accounts for retention and log compaction)
 		messagesInPartition := newestOffset - oldestOffset
+		counts[int32(partition)] = messagesInPartition
 		total += messagesInPartition
 	}
 
-	return total
-}
-
-func generateDOTFile(info *KafkaClusterInfo, filename string) error {
-	var dot strings.Builder
-
-	// DOT header
-	dot.WriteString("digraph KafkaCluster {\n")
-	dot.WriteString("  rankdir=LR;\n")
-	dot.WriteString("  node [shape=box, style=rounded];\n")
-	dot.WriteString("  graph [splines=true, overlap=false];\n\n")
-
-	// Define subgraphs for topics and consumers
-	dot.WriteString("  // Topics\n")
-	dot.WriteString("  subgraph cluster_topics {\n")
-	dot.WriteString("    label=\"Topics\";\n")
-	dot.WriteString("    style=filled;\n")
-	dot.WriteString("    color=lightgrey;\n")
-	dot.WriteString("    node [style=filled, fillcolor=\"#667eea\", fontcolor=white];\n")
-
-	for _, topic := range info.Topics {
-		// Escape special characters
-		safeName := strings.ReplaceAll(topic.Name, "\"", "\\\"")
-		safeName = strings.ReplaceAll(safeName, "-", "_")
-		safeName = strings.ReplaceAll(safeName, ".", "_")
-		label := topic.Name
-		if len(label) > 30 {
-			label = label[:27] + "..."
-		}
-		dot.WriteString(fmt.Sprintf("    topic_%s [label=\"%s\\n(%d partitions)\"];\n",
-			safeName, label, topic.Partitions))
-	}
-	dot.WriteString("  }\n\n")
-
-	// Consumer groups
-	dot.WriteString("  // Consumer Groups\n")
-	dot.WriteString("  subgraph cluster_consumers {\n")
-	dot.WriteString("    label=\"Consumer Groups\";\n")
-	dot.WriteString("    style=filled;\n")
-	dot.WriteString("    color=lightblue;\n")
-	dot.WriteString("    node [style=filled, fillcolor=\"#43e97b\", fontcolor=white];\n")
-
-	for _, group := range info.ConsumerGroups {
-		safeName := strings.ReplaceAll(group.Name, "\"", "\\\"")
-		safeName = strings.ReplaceAll(safeName, "-", "_")
-		safeName = strings.ReplaceAll(safeName, ".", "_")
-		label := group.Name
-		if len(label) > 30 {
-			label = label[:27] + "..."
-		}
-		dot.WriteString(fmt.Sprintf("    consumer_%s [label=\"%s\\n(%d members, %s)\"];\n",
-			safeName, label, group.Members, group.State))
-	}
-	dot.WriteString("  }\n\n")
-
-	// Edges (connections)
-	dot.WriteString("  // Subscriptions\n")
-	for _, group := range info.ConsumerGroups {
-		safeGroupName := strings.ReplaceAll(group.Name, "\"", "\\\"")
-		safeGroupName = strings.ReplaceAll(safeGroupName, "-", "_")
-		safeGroupName = strings.ReplaceAll(safeGroupName, ".", "_")
-
-		for _, topic := range group.Topics {
-			safeTopicName := strings.ReplaceAll(topic, "\"", "\\\"")
-			safeTopicName = strings.ReplaceAll(safeTopicName, "-", "_")
-			safeTopicName = strings.ReplaceAll(safeTopicName, ".", "_")
-			dot.WriteString(fmt.Sprintf("  topic_%s -> consumer_%s [color=\"#667eea\", penwidth=2.0];\n",
-				safeTopicName, safeGroupName))
-		}
-	}
-
-	dot.WriteString("}\n")
-
-	return os.WriteFile(filename, []byte(dot.String()), 0644)
+	return total, counts
 }
 
 func countInternalTopics(topics []TopicInfo) int {
@@ -1046,7 +1120,17 @@ func countInternalTopics(topics []TopicInfo) int {
 	return count
 }
 
-func generateRecreateScript(info *KafkaClusterInfo, filename string) error {
+// RecreateOptions configure generateRecreateScript
+type RecreateOptions struct {
+	CommandConfig string
+	Renames       *TopicRenames   // target names, from -rename-map
+	Overrides     *TopicOverrides // partitions, replication factor and configs on the target
+	Dialect       string          // CLI used by the script: kafka, rpk or confluent
+}
+
+// generateRecreateScript writes a script creating the topics on a target cluster, renamed and
+// adapted by the options
+func generateRecreateScript(info *KafkaClusterInfo, filename string, opts RecreateOptions) error {
 	var script strings.Builder
 
 	// Script header
@@ -1057,26 +1141,32 @@ func generateRecreateScript(info *KafkaClusterInfo, filename string) error {
 	script.WriteString(fmt.Sprintf("# Total Topics: %d\n", info.TotalTopics))
 	script.WriteString("#\n")
 	script.WriteString("# Usage:\n")
-	script.WriteString("#   1. Edit BOOTSTRAP_SERVERS to point to your target cluster\n")
-	script.WriteString("#   2. Add authentication flags if needed (--command-config, etc.)\n")
+	switch opts.Dialect {
+	case DialectRpk:
+		script.WriteString("#   1. Edit BOOTSTRAP_SERVERS to point to your target cluster\n")
+		script.WriteString("#   2. Set RPK_FLAGS or an rpk profile if authentication is needed\n")
+	case DialectConfluent:
+		script.WriteString("#   1. Log in with confluent login and set CLUSTER_ID to your target cluster\n")
+		script.WriteString("#   2. Topics are created with the cluster's replication factor\n")
+	default:
+		script.WriteString("#   1. Edit BOOTSTRAP_SERVERS to point to your target cluster\n")
+		script.WriteString("#   2. Add authentication flags if needed (--command-config, etc.)\n")
+	}
 	script.WriteString(fmt.Sprintf("#   3. Run: chmod +x %s && ./%s\n", filename, filename))
 	script.WriteString("#\n\n")
 
 	script.WriteString("set -e  # Exit on error\n\n")
 
-	script.WriteString("# Target cluster configuration\n")
-	script.WriteString("BOOTSTRAP_SERVERS=\"localhost:9092\"  # CHANGE THIS\n")
-	script.WriteString("# Uncomment and configure if authentication is needed:\n")
-	script.WriteString("# COMMAND_CONFIG=\"--command-config client.properties\"\n")
-	script.WriteString("COMMAND_CONFIG=\"\"\n\n")
-
-	script.WriteString("# Kafka topics command (adjust path if needed)\n")
-	script.WriteString("KAFKA_TOPICS=\"kafka-topics.sh\"\n\n")
+	script.WriteString(recreateToolLines(opts.Dialect, opts.CommandConfig))
 
 	script.WriteString("echo \"========================================\"\n")
 	script.WriteString(fmt.Sprintf("echo \"Recreating topics from source cluster\"\n"))
 	script.WriteString(fmt.Sprintf("echo \"Note: Skipping %d internal topics (starting with __)\\n\"\n", countInternalTopics(info.Topics)))
-	script.WriteString("echo \"Target: $BOOTSTRAP_SERVERS\"\n")
+	if opts.Dialect == DialectConfluent {
+		script.WriteString("echo \"Target: $CLUSTER_ID\"\n")
+	} else {
+		script.WriteString("echo \"Target: $BOOTSTRAP_SERVERS\"\n")
+	}
 	script.WriteString("echo \"========================================\"\n")
 	script.WriteString("echo \"\"\n\n")
 
@@ -1094,42 +1184,18 @@ func generateRecreateScript(info *KafkaClusterInfo, filename string) error {
 		}
 
 		topicIndex++
-		script.WriteString(fmt.Sprintf("# Topic %d: %s\n", topicIndex, topic.Name))
-		script.WriteString(fmt.Sprintf("echo \"[%d] Creating topic: %s\"\n", topicIndex, topic.Name))
-
-		// Build the kafka-topics command
-		cmd := fmt.Sprintf("if $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG \\\n")
-		cmd += fmt.Sprintf("  --create \\\n")
-		cmd += fmt.Sprintf("  --topic \"%s\" \\\n", topic.Name)
-		cmd += fmt.Sprintf("  --partitions %d \\\n", topic.Partitions)
-		cmd += fmt.Sprintf("  --replication-factor %d", topic.ReplicationFactor)
-
-		// Add configurations
-		if len(topic.Configs) > 0 {
-			cmd += " \\\n"
-			configs := make([]string, 0, len(topic.Configs))
-			for k, v := range topic.Configs {
-				configs = append(configs, fmt.Sprintf("%s=%s", k, v))
-			}
-			sort.Strings(configs)
-			for j, config := range configs {
-				cmd += fmt.Sprintf("  --config \"%s\"", config)
-				if j < len(configs)-1 {
-					cmd += " \\\n"
-				}
-			}
+		name := opts.Renames.Rename(topic.Name)
+		if name != topic.Name {
+			script.WriteString(fmt.Sprintf("# Topic %d: %s (source: %s)\n", topicIndex, name, topic.Name))
+		} else {
+			script.WriteString(fmt.Sprintf("# Topic %d: %s\n", topicIndex, name))
 		}
-
-		cmd += "; then\n"
-		cmd += "  echo \"  ✓ Created successfully\"\n"
-		cmd += "  ((CREATED++))\n"
-		cmd += "else\n"
-		cmd += "  echo \"  ✗ Failed to create (may already exist)\"\n"
-		cmd += fmt.Sprintf("  FAILED_TOPICS+=(\"  - %s\")\n", topic.Name)
-		cmd += "  ((FAILED++))\n"
-		cmd += "fi\n"
-
-		script.WriteString(cmd)
+		topic, notes := opts.Overrides.apply(topic)
+		for _, note := range notes {
+			script.WriteString(fmt.Sprintf("#   override: %s\n", note))
+		}
+		script.WriteString(fmt.Sprintf("echo \"[%d] Creating topic:\" %s\n", topicIndex, shellQuote(name)))
+		script.WriteString(createTopicCommand(opts.Dialect, name, topic, topicIndex))
 		script.WriteString("echo \"\"\n\n")
 	}
 
@@ -1137,7 +1203,12 @@ func generateRecreateScript(info *KafkaClusterInfo, filename string) error {
 	script.WriteString("echo \"========================================\"\n")
 	script.WriteString("echo \"Topic Recreation Summary:\"\n")
 	script.WriteString("echo \"  Successfully created: $CREATED\"\n")
-	script.WriteString("echo \"  Failed/Skipped: $FAILED\"\n")
+	if opts.Dialect == DialectRpk || opts.Dialect == DialectConfluent {
+		script.WriteString("echo \"  Already existed: $SKIPPED\"\n")
+		script.WriteString("echo \"  Failed: $FAILED\"\n")
+	} else {
+		script.WriteString("echo \"  Failed/Skipped: $FAILED\"\n")
+	}
 	script.WriteString(fmt.Sprintf("echo \"  Internal topics skipped: %d\"\n", countInternalTopics(info.Topics)))
 	script.WriteString("if [ $FAILED -gt 0 ]; then\n")
 	script.WriteString("  echo \"\"\n")
@@ -1146,9 +1217,7 @@ func generateRecreateScript(info *KafkaClusterInfo, filename string) error {
 	script.WriteString("fi\n")
 	script.WriteString("echo \"========================================\"\n\n")
 
-	script.WriteString("# Note: To verify topics were created correctly:\n")
-	script.WriteString("# $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --list\n")
-	script.WriteString("# $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --describe --topic <topic-name>\n")
+	script.WriteString(recreateVerifyLines(opts.Dialect))
 
 	return os.WriteFile(filename, []byte(script.String()), 0755)
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Committed offset classes, relative to the partition's current watermarks
+const (
+	OffsetOK               = "ok"                // between the low and high watermark, inclusive
+	OffsetOutOfRangeLow    = "out-of-range-low"  // below the low watermark: retention deleted unread records
+	OffsetOutOfRangeHigh   = "out-of-range-high" // beyond the high watermark: invalid on this cluster
+	OffsetMissingPartition = "missing-partition" // the topic or partition does not exist
+)
+
+// offsetCheckPrintLimit is the number of problem offsets printed to the console; the report has all
+const offsetCheckPrintLimit = 50
+
+// OffsetCheckReport classifies committed offsets, live or from a backup, against this cluster
+type OffsetCheckReport struct {
+	Timestamp       string         `json:"timestamp"`
+	Cluster         string         `json:"cluster"`
+	Source          string         `json:"source"`                     // "committed" or the backup file
+	BackupTimestamp string         `json:"backup_timestamp,omitempty"` // when the backup was taken
+	Checked         int            `json:"checked"`
+	Counts          map[string]int `json:"counts"`
+	Offsets         []OffsetCheck  `json:"offsets"`
+}
+
+// OffsetCheck is one committed offset and what a consumer would do with it
+type OffsetCheck struct {
+	Group         string        `json:"group"`
+	Topic         string        `json:"topic"`
+	SourceTopic   string        `json:"source_topic,omitempty"` // name in the backup, if renamed
+	Partition     int           `json:"partition"`
+	Offset        int64         `json:"offset"`
+	LowWatermark  int64         `json:"low_watermark"`  // -1 if the partition is missing
+	HighWatermark int64         `json:"high_watermark"` // -1 if the partition is missing
+	Status        string        `json:"status"`
+	Earliest      *ResetOutcome `json:"reset_earliest,omitempty"` // with auto.offset.reset=earliest
+	Latest        *ResetOutcome `json:"reset_latest,omitempty"`   // with auto.offset.reset=latest
+}
+
+// ResetOutcome is where a consumer continues after an out-of-range offset is reset.
+// With auto.offset.reset=none the consumer fails with OffsetOutOfRangeException instead.
+type ResetOutcome struct {
+	Offset      int64 `json:"offset"`
+	Lost        int64 `json:"lost,omitempty"`        // records deleted by retention before they were read
+	Skipped     int64 `json:"skipped,omitempty"`     // retained records that are never read
+	Reprocessed int64 `json:"reprocessed,omitempty"` // retained records read again
+}
+
+// checkOffsets classifies every offset in backup against the current watermarks. Topics are
+// renamed first, so a source cluster backup can be checked against its target. allTopics is
+// every topic in the cluster.
+func checkOffsets(client sarama.Client, backup *ConsumerOffsetsBackup, renames *TopicRenames, allTopics []string, cluster string) *OffsetCheckReport {
+	report := &OffsetCheckReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Cluster:   cluster,
+		Counts:    make(map[string]int),
+		Offsets:   make([]OffsetCheck, 0),
+	}
+
+	exists := make(map[string]bool, len(allTopics))
+	for _, name := range allTopics {
+		exists[name] = true
+	}
+
+	// Watermarks are shared by all groups reading a partition
+	type watermarks struct{ low, high int64 }
+	cache := make(map[string]map[int]*watermarks)
+	partitionWatermarks := func(topic string, partition int) *watermarks {
+		if cache[topic] == nil {
+			cache[topic] = make(map[int]*watermarks)
+		}
+		if w, ok := cache[topic][partition]; ok {
+			return w
+		}
+		var w *watermarks
+		low, err := client.GetOffset(topic, int32(partition), sarama.OffsetOldest)
+		if err == nil {
+			var high int64
+			if high, err = client.GetOffset(topic, int32(partition), sarama.OffsetNewest); err == nil {
+				w = &watermarks{low, high}
+			}
+		}
+		if err != nil && err != sarama.ErrUnknownTopicOrPartition {
+			log.Printf("Warning: Could not get watermarks for topic %s partition %d: %v", topic, partition, err)
+		}
+		cache[topic][partition] = w
+		return w
+	}
+
+	for _, group := range backup.ConsumerGroups {
+		for source, partitions := range group.Topics {
+			topic := renames.Rename(source)
+			var ids []int32
+			if exists[topic] {
+				var err error
+				if ids, err = client.Partitions(topic); err != nil {
+					log.Printf("Warning: Could not get partitions for topic %s: %v", topic, err)
+				}
+			}
+			present := make(map[int]bool, len(ids))
+			for _, id := range ids {
+				present[int(id)] = true
+			}
+
+			for _, p := range partitions {
+				check := OffsetCheck{
+					Group:         group.Group,
+					Topic:         topic,
+					Partition:     p.Partition,
+					Offset:        p.Offset,
+					LowWatermark:  -1,
+					HighWatermark: -1,
+					Status:        OffsetMissingPartition,
+				}
+				if topic != source {
+					check.SourceTopic = source
+				}
+				if present[p.Partition] {
+					if w := partitionWatermarks(topic, p.Partition); w != nil {
+						check.LowWatermark, check.HighWatermark = w.low, w.high
+						classifyOffset(&check)
+					}
+				}
+				report.Offsets = append(report.Offsets, check)
+				report.Counts[check.Status]++
+			}
+		}
+	}
+
+	sort.Slice(report.Offsets, func(i, j int) bool {
+		a, b := report.Offsets[i], report.Offsets[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	report.Checked = len(report.Offsets)
+	return report
+}
+
+// classifyOffset sets the status of an offset with known watermarks and predicts what
+// auto.offset.reset does with an out-of-range offset. The high watermark itself is valid:
+// the consumer waits for the next record.
+func classifyOffset(check *OffsetCheck) {
+	low, high := check.LowWatermark, check.HighWatermark
+	switch {
+	case check.Offset < low:
+		check.Status = OffsetOutOfRangeLow
+		lost := low - check.Offset
+		check.Earliest = &ResetOutcome{Offset: low, Lost: lost}
+		check.Latest = &ResetOutcome{Offset: high, Lost: lost, Skipped: high - low}
+	case check.Offset > high:
+		check.Status = OffsetOutOfRangeHigh
+		// Everything retained was below the committed offset, so it counts as read
+		check.Earliest = &ResetOutcome{Offset: low, Reprocessed: high - low}
+		check.Latest = &ResetOutcome{Offset: high}
+	default:
+		check.Status = OffsetOK
+	}
+}
+
+// describeReset summarizes a reset outcome, e.g. "offset 120, 20 lost"
+func describeReset(outcome *ResetOutcome) string {
+	parts := []string{fmt.Sprintf("offset %d", outcome.Offset)}
+	if outcome.Lost > 0 {
+		parts = append(parts, fmt.Sprintf("%s lost", formatNumber(outcome.Lost)))
+	}
+	if outcome.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%s skipped", formatNumber(outcome.Skipped)))
+	}
+	if outcome.Reprocessed > 0 {
+		parts = append(parts, fmt.Sprintf("%s re-read", formatNumber(outcome.Reprocessed)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printOffsetCheck prints the counts and the first problem offsets
+func printOffsetCheck(report *OffsetCheckReport) {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Committed Offset Check (%s)\n", report.Source)
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("Checked:            %d\n", report.Checked)
+	fmt.Printf("OK:                 %d\n", report.Counts[OffsetOK])
+	fmt.Printf("Out of range, low:  %d (data lost to retention)\n", report.Counts[OffsetOutOfRangeLow])
+	fmt.Printf("Out of range, high: %d (invalid)\n", report.Counts[OffsetOutOfRangeHigh])
+	fmt.Printf("Missing partitions: %d\n", report.Counts[OffsetMissingPartition])
+
+	printed := 0
+	for _, check := range report.Offsets {
+		if check.Status == OffsetOK {
+			continue
+		}
+		if printed == offsetCheckPrintLimit {
+			fmt.Printf("  ... and %d more, see the report\n", report.Checked-report.Counts[OffsetOK]-printed)
+			break
+		}
+		printed++
+		fmt.Printf("  %-30s %s[%d] offset %d: %s", check.Group, check.Topic, check.Partition, check.Offset, check.Status)
+		if check.Earliest != nil {
+			fmt.Printf(" (watermarks %d-%d)\n", check.LowWatermark, check.HighWatermark)
+			fmt.Printf("      earliest: %s; latest: %s; none: consumer fails\n", describeReset(check.Earliest), describeReset(check.Latest))
+		} else {
+			fmt.Println()
+		}
+	}
+	fmt.Println()
+}
+
+// saveOffsetCheck writes the offset check report to a JSON file
+func saveOffsetCheck(report *OffsetCheckReport, filename string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal offset check: %w", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyOffset(t *testing.T) {
+	const low, high = 100, 150
+	tests := []struct {
+		offset   int64
+		status   string
+		earliest *ResetOutcome
+		latest   *ResetOutcome
+	}{
+		{low, OffsetOK, nil, nil},
+		{high, OffsetOK, nil, nil}, // the consumer waits for the next record
+		{low + 10, OffsetOK, nil, nil},
+		{
+			low - 1, OffsetOutOfRangeLow,
+			&ResetOutcome{Offset: low, Lost: 1},
+			&ResetOutcome{Offset: high, Lost: 1, Skipped: high - low},
+		},
+		{
+			0, OffsetOutOfRangeLow,
+			&ResetOutcome{Offset: low, Lost: low},
+			&ResetOutcome{Offset: high, Lost: low, Skipped: high - low},
+		},
+		{
+			high + 1, OffsetOutOfRangeHigh,
+			&ResetOutcome{Offset: low, Reprocessed: high - low},
+			&ResetOutcome{Offset: high},
+		},
+	}
+	for _, tt := range tests {
+		check := OffsetCheck{Offset: tt.offset, LowWatermark: low, HighWatermark: high}
+		classifyOffset(&check)
+		if check.Status != tt.status {
+			t.Errorf("offset %d: status %s, want %s", tt.offset, check.Status, tt.status)
+		}
+		if !reflect.DeepEqual(check.Earliest, tt.earliest) {
+			t.Errorf("offset %d: earliest %+v, want %+v", tt.offset, check.Earliest, tt.earliest)
+		}
+		if !reflect.DeepEqual(check.Latest, tt.latest) {
+			t.Errorf("offset %d: latest %+v, want %+v", tt.offset, check.Latest, tt.latest)
+		}
+	}
+}
+
+func TestDescribeReset(t *testing.T) {
+	outcome := &ResetOutcome{Offset: 150, Lost: 1, Skipped: 50}
+	if got, want := describeReset(outcome), "offset 150, 1 lost, 50 skipped"; got != want {
+		t.Errorf("describeReset = %q, want %q", got, want)
+	}
+	if got, want := describeReset(&ResetOutcome{Offset: 100, Reprocessed: 50}), "offset 100, 50 re-read"; got != want {
+		t.Errorf("describeReset = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// consumerOffsetsTopic is the internal topic where the group coordinator stores committed
+// offsets and group metadata
+const consumerOffsetsTopic = "__consumer_offsets"
+
+// __consumer_offsets key versions. Newer versions belong to the KIP-848 consumer group
+// protocol and are not decoded.
+const (
+	offsetCommitKeyV0  = 0 // group, topic, partition
+	offsetCommitKeyV1  = 1 // same layout as v0
+	groupMetadataKeyV2 = 2 // group
+)
+
+// offsetsTopicKey is a decoded __consumer_offsets record key. Topic and Partition are only set
+// for offset commits.
+type offsetsTopicKey struct {
+	Version   int16
+	Group     string
+	Topic     string
+	Partition int32
+}
+
+// offsetCommitValue is a committed offset (OffsetCommitValue v0-v4)
+type offsetCommitValue struct {
+	Offset          int64
+	LeaderEpoch     int32 // v3+, -1 if unknown
+	Metadata        string
+	CommitTimestamp int64 // ms
+	ExpireTimestamp int64 // v1 only, -1 otherwise
+}
+
+// groupMetadataValue is the state of a group after a rebalance (GroupMetadataValue v0-v4)
+type groupMetadataValue struct {
+	ProtocolType          string
+	Generation            int32
+	Protocol              string
+	Leader                string
+	CurrentStateTimestamp int64 // v2+, -1 if unknown
+	Members               []groupMemberValue
+}
+
+// groupMemberValue is one member in a group metadata record
+type groupMemberValue struct {
+	MemberID         string
+	GroupInstanceID  string // v3+, static membership
+	ClientID         string
+	ClientHost       string
+	RebalanceTimeout int32 // v1+
+	SessionTimeout   int32
+	Subscription     []byte
+	Assignment       []byte
+}
+
+// offsetsTopicReader decodes the Kafka protocol types used in __consumer_offsets records.
+// Flexible versions use compact strings, bytes and arrays and end structs with tagged fields.
+// The first error sticks, so fields can be read without checking every call.
+type offsetsTopicReader struct {
+	data     []byte
+	pos      int
+	flexible bool
+	err      error
+}
+
+func (r *offsetsTopicReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("record truncated at byte %d", r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *offsetsTopicReader) int16() int16 {
+	if b := r.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *offsetsTopicReader) int32() int32 {
+	if b := r.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *offsetsTopicReader) int64() int64 {
+	if b := r.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *offsetsTopicReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.err = fmt.Errorf("invalid varint at byte %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+// length reads a string, bytes or array length; -1 is null
+func (r *offsetsTopicReader) length(int16Length bool) int {
+	switch {
+	case r.flexible:
+		return int(r.uvarint()) - 1
+	case int16Length:
+		return int(r.int16())
+	}
+	return int(r.int32())
+}
+
+// string reads a string; null reads as ""
+func (r *offsetsTopicReader) string() string {
+	n := r.length(true)
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(n))
+}
+
+func (r *offsetsTopicReader) bytes() []byte {
+	n := r.length(false)
+	if n < 0 {
+		return nil
+	}
+	return r.take(n)
+}
+
+func (r *offsetsTopicReader) arrayLength() int {
+	return r.length(false)
+}
+
+// skipTags skips the tagged fields that end a struct in flexible versions
+func (r *offsetsTopicReader) skipTags() {
+	if !r.flexible {
+		return
+	}
+	for count := r.uvarint(); count > 0 && r.err == nil; count-- {
+		r.uvarint() // tag
+		r.take(int(r.uvarint()))
+	}
+}
+
+// decodeOffsetsKey decodes a record key. Keys of unknown versions are returned with only
+// Version set.
+func decodeOffsetsKey(data []byte) (*offsetsTopicKey, error) {
+	r := &offsetsTopicReader{data: data}
+	key := &offsetsTopicKey{Version: r.int16()}
+	switch key.Version {
+	case offsetCommitKeyV0, offsetCommitKeyV1:
+		key.Group = r.string()
+		key.Topic = r.string()
+		key.Partition = r.int32()
+	case groupMetadataKeyV2:
+		key.Group = r.string()
+	}
+	return key, r.err
+}
+
+// decodeOffsetCommitValue decodes an OffsetCommitValue
+func decodeOffsetCommitValue(data []byte) (*offsetCommitValue, error) {
+	r := &offsetsTopicReader{data: data}
+	version := r.int16()
+	if r.err == nil && (version < 0 || version > 4) {
+		return nil, fmt.Errorf("unknown offset commit value version %d", version)
+	}
+	r.flexible = version >= 4
+
+	value := &offsetCommitValue{LeaderEpoch: -1, ExpireTimestamp: -1}
+	value.Offset = r.int64()
+	if version >= 3 {
+		value.LeaderEpoch = r.int32()
+	}
+	value.Metadata = r.string()
+	value.CommitTimestamp = r.int64()
+	if version == 1 {
+		value.ExpireTimestamp = r.int64()
+	}
+	r.skipTags()
+	return value, r.err
+}
+
+// decodeGroupMetadataValue decodes a GroupMetadataValue
+func decodeGroupMetadataValue(data []byte) (*groupMetadataValue, error) {
+	r := &offsetsTopicReader{data: data}
+	version := r.int16()
+	if r.err == nil && (version < 0 || version > 4) {
+		return nil, fmt.Errorf("unknown group metadata value version %d", version)
+	}
+	r.flexible = version >= 4
+
+	value := &groupMetadataValue{CurrentStateTimestamp: -1}
+	value.ProtocolType = r.string()
+	value.Generation = r.int32()
+	value.Protocol = r.string()
+	value.Leader = r.string()
+	if version >= 2 {
+		value.CurrentStateTimestamp = r.int64()
+	}
+
+	count := r.arrayLength()
+	for i := 0; i < count && r.err == nil; i++ {
+		var member groupMemberValue
+		member.MemberID = r.string()
+		if version >= 3 {
+			member.GroupInstanceID = r.string()
+		}
+		member.ClientID = r.string()
+		member.ClientHost = r.string()
+		if version >= 1 {
+			member.RebalanceTimeout = r.int32()
+		}
+		member.SessionTimeout = r.int32()
+		member.Subscription = r.bytes()
+		member.Assignment = r.bytes()
+		r.skipTags()
+		value.Members = append(value.Members, member)
+	}
+	r.skipTags()
+	return value, r.err
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// offsetsFixture encodes __consumer_offsets records the way the group coordinator does
+type offsetsFixture struct {
+	data     []byte
+	flexible bool
+}
+
+func (f *offsetsFixture) int16(v int16) *offsetsFixture {
+	f.data = binary.BigEndian.AppendUint16(f.data, uint16(v))
+	return f
+}
+
+func (f *offsetsFixture) int32(v int32) *offsetsFixture {
+	f.data = binary.BigEndian.AppendUint32(f.data, uint32(v))
+	return f
+}
+
+func (f *offsetsFixture) int64(v int64) *offsetsFixture {
+	f.data = binary.BigEndian.AppendUint64(f.data, uint64(v))
+	return f
+}
+
+func (f *offsetsFixture) string(s string) *offsetsFixture {
+	if f.flexible {
+		f.data = binary.AppendUvarint(f.data, uint64(len(s)+1))
+	} else {
+		f.int16(int16(len(s)))
+	}
+	f.data = append(f.data, s...)
+	return f
+}
+
+func (f *offsetsFixture) nullString() *offsetsFixture {
+	if f.flexible {
+		f.data = append(f.data, 0)
+		return f
+	}
+	return f.int16(-1)
+}
+
+func (f *offsetsFixture) bytes(b []byte) *offsetsFixture {
+	if f.flexible {
+		f.data = binary.AppendUvarint(f.data, uint64(len(b)+1))
+	} else {
+		f.int32(int32(len(b)))
+	}
+	f.data = append(f.data, b...)
+	return f
+}
+
+func (f *offsetsFixture) array(n int) *offsetsFixture {
+	if f.flexible {
+		f.data = binary.AppendUvarint(f.data, uint64(n+1))
+		return f
+	}
+	return f.int32(int32(n))
+}
+
+// tags writes the tagged fields that end a flexible struct, with one unknown tag to skip
+func (f *offsetsFixture) tags() *offsetsFixture {
+	if f.flexible {
+		f.data = append(f.data, 1, 9, 2, 0xab, 0xcd)
+	}
+	return f
+}
+
+func offsetCommitKeyFixture(version int16, group, topic string, partition int32) []byte {
+	f := &offsetsFixture{}
+	return f.int16(version).string(group).string(topic).int32(partition).data
+}
+
+func TestDecodeOffsetsKey(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want offsetsTopicKey
+	}{
+		{"commit v0", offsetCommitKeyFixture(0, "billing", "orders", 3), offsetsTopicKey{Version: 0, Group: "billing", Topic: "orders", Partition: 3}},
+		{"commit v1", offsetCommitKeyFixture(1, "billing", "orders", 3), offsetsTopicKey{Version: 1, Group: "billing", Topic: "orders", Partition: 3}},
+		{"group metadata v2", (&offsetsFixture{}).int16(2).string("billing").data, offsetsTopicKey{Version: 2, Group: "billing"}},
+		{"unknown version", (&offsetsFixture{}).int16(3).string("billing").data, offsetsTopicKey{Version: 3}},
+	}
+	for _, tt := range tests {
+		key, err := decodeOffsetsKey(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if *key != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *key, tt.want)
+		}
+	}
+
+	full := offsetCommitKeyFixture(1, "billing", "orders", 3)
+	for _, n := range []int{0, 1, 5, len(full) - 1} {
+		if _, err := decodeOffsetsKey(full[:n]); err == nil {
+			t.Errorf("key truncated to %d bytes: expected an error", n)
+		}
+	}
+}
+
+func TestDecodeOffsetCommitValue(t *testing.T) {
+	const offset, epoch, commitTs, expireTs = 4200, 7, 1704164645000, 1704769445000
+	tests := []struct {
+		version int16
+		data    []byte
+		want    offsetCommitValue
+	}{
+		{
+			0, (&offsetsFixture{}).int16(0).int64(offset).string("meta").int64(commitTs).data,
+			offsetCommitValue{Offset: offset, LeaderEpoch: -1, Metadata: "meta", CommitTimestamp: commitTs, ExpireTimestamp: -1},
+		},
+		{
+			1, (&offsetsFixture{}).int16(1).int64(offset).string("meta").int64(commitTs).int64(expireTs).data,
+			offsetCommitValue{Offset: offset, LeaderEpoch: -1, Metadata: "meta", CommitTimestamp: commitTs, ExpireTimestamp: expireTs},
+		},
+		{
+			2, (&offsetsFixture{}).int16(2).int64(offset).string("").int64(commitTs).data,
+			offsetCommitValue{Offset: offset, LeaderEpoch: -1, CommitTimestamp: commitTs, ExpireTimestamp: -1},
+		},
+		{
+			3, (&offsetsFixture{}).int16(3).int64(offset).int32(epoch).nullString().int64(commitTs).data,
+			offsetCommitValue{Offset: offset, LeaderEpoch: epoch, CommitTimestamp: commitTs, ExpireTimestamp: -1},
+		},
+		{
+			4, func() []byte {
+				f := (&offsetsFixture{}).int16(4)
+				f.flexible = true
+				return f.int64(offset).int32(epoch).string("meta").int64(commitTs).tags().data
+			}(),
+			offsetCommitValue{Offset: offset, LeaderEpoch: epoch, Metadata: "meta", CommitTimestamp: commitTs, ExpireTimestamp: -1},
+		},
+	}
+	for _, tt := range tests {
+		value, err := decodeOffsetCommitValue(tt.data)
+		if err != nil {
+			t.Errorf("v%d: %v", tt.version, err)
+			continue
+		}
+		if *value != tt.want {
+			t.Errorf("v%d: got %+v, want %+v", tt.version, *value, tt.want)
+		}
+		for n := 0; n < len(tt.data); n++ {
+			if _, err := decodeOffsetCommitValue(tt.data[:n]); err == nil {
+				t.Errorf("v%d truncated to %d bytes: expected an error", tt.version, n)
+			}
+		}
+	}
+
+	if _, err := decodeOffsetCommitValue((&offsetsFixture{}).int16(5).int64(offset).data); err == nil {
+		t.Error("v5: expected an unknown version error")
+	}
+}
+
+func TestDecodeGroupMetadataValue(t *testing.T) {
+	const stateTs = 1704164645000
+	subscription := []byte{0, 1, 2}
+	assignment := []byte{3, 4}
+
+	encode := func(version int16) []byte {
+		f := (&offsetsFixture{}).int16(version)
+		f.flexible = version >= 4
+		f.string("consumer").int32(12).string("range").string("member-1")
+		if version >= 2 {
+			f.int64(stateTs)
+		}
+		f.array(1).string("member-1")
+		if version >= 3 {
+			f.string("instance-1")
+		}
+		f.string("client-1").string("/10.0.0.1")
+		if version >= 1 {
+			f.int32(300000)
+		}
+		f.int32(45000).bytes(subscription).bytes(assignment).tags()
+		return f.tags().data
+	}
+
+	for version := int16(0); version <= 4; version++ {
+		want := groupMetadataValue{
+			ProtocolType:          "consumer",
+			Generation:            12,
+			Protocol:              "range",
+			Leader:                "member-1",
+			CurrentStateTimestamp: -1,
+			Members: []groupMemberValue{{
+				MemberID:       "member-1",
+				ClientID:       "client-1",
+				ClientHost:     "/10.0.0.1",
+				SessionTimeout: 45000,
+				Subscription:   subscription,
+				Assignment:     assignment,
+			}},
+		}
+		if version >= 1 {
+			want.Members[0].RebalanceTimeout = 300000
+		}
+		if version >= 2 {
+			want.CurrentStateTimestamp = stateTs
+		}
+		if version >= 3 {
+			want.Members[0].GroupInstanceID = "instance-1"
+		}
+
+		data := encode(version)
+		value, err := decodeGroupMetadataValue(data)
+		if err != nil {
+			t.Errorf("v%d: %v", version, err)
+			continue
+		}
+		if !reflect.DeepEqual(*value, want) {
+			t.Errorf("v%d: got %+v, want %+v", version, *value, want)
+		}
+		for n := 0; n < len(data); n++ {
+			if _, err := decodeGroupMetadataValue(data[:n]); err == nil {
+				t.Errorf("v%d truncated to %d bytes: expected an error", version, n)
+			}
+		}
+	}
+
+	// An empty group after all members left: null protocol and leader, no members
+	empty := (&offsetsFixture{}).int16(3).string("consumer").int32(13).nullString().nullString().int64(stateTs).array(0).data
+	value, err := decodeGroupMetadataValue(empty)
+	if err != nil {
+		t.Fatalf("empty group: %v", err)
+	}
+	if value.Protocol != "" || value.Leader != "" || len(value.Members) != 0 || value.Generation != 13 {
+		t.Errorf("empty group: unexpected %+v", *value)
+	}
+
+	if _, err := decodeGroupMetadataValue((&offsetsFixture{}).int16(5).data); err == nil {
+		t.Error("v5: expected an unknown version error")
+	}
+}
+
+func TestReplayOffsetsRecordTombstones(t *testing.T) {
+	groups := make(map[string]*groupHistory)
+	group := func(name string) *groupHistory {
+		if groups[name] == nil {
+			groups[name] = &groupHistory{
+				report:     GroupCommitHistory{Group: name},
+				partitions: make(map[string]map[int32]*PartitionCommit),
+			}
+		}
+		return groups[name]
+	}
+	commit := func(partition int32, offset int64) fetchedRecord {
+		return fetchedRecord{
+			Key:   offsetCommitKeyFixture(1, "billing", "orders", partition),
+			Value: (&offsetsFixture{}).int16(3).int64(offset).int32(0).string("").int64(1704164645000).data,
+		}
+	}
+	replay := func(rec fetchedRecord) {
+		t.Helper()
+		if !replayOffsetsRecord(rec, group, CommitHistoryOptions{}) {
+			t.Fatalf("record not decoded: %+v", rec)
+		}
+	}
+
+	replay(commit(0, 10))
+	replay(commit(1, 20))
+	// Offset tombstone: partition 0's offset expired
+	replay(fetchedRecord{Key: offsetCommitKeyFixture(1, "billing", "orders", 0)})
+
+	g := groups["billing"].finish(time.Now(), 0)
+	if len(g.Partitions) != 1 || g.Partitions[0].Partition != 1 || g.Partitions[0].Offset != 20 {
+		t.Errorf("expected only partition 1 after the offset tombstone, got %+v", g.Partitions)
+	}
+
+	// Group tombstone: the group was deleted
+	replay(fetchedRecord{Key: (&offsetsFixture{}).int16(2).string("billing").data})
+	g = groups["billing"].finish(time.Now(), 0)
+	if !g.Deleted || len(g.Partitions) != 0 {
+		t.Errorf("expected a deleted group without offsets, got %+v", g)
+	}
+
+	// A record without a key is not an offsets record
+	if replayOffsetsRecord(fetchedRecord{Value: []byte{0}}, group, CommitHistoryOptions{}) {
+		t.Error("record without a key was decoded")
+	}
+}
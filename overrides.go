@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// defaultDeniedConfigs are topic configs not copied to a target cluster unless allowed:
+// broker-managed settings and vendor-specific ones that other clusters reject
+var defaultDeniedConfigs = []string{
+	"message.format.version",                  // deprecated, ignored or rejected since Kafka 3.0
+	"leader.replication.throttled.replicas",   // set by partition reassignments
+	"follower.replication.throttled.replicas", // set by partition reassignments
+	"confluent.*",                             // Confluent Platform and Cloud only
+}
+
+// TopicOverrides adapt topics to a target cluster when recreating them
+type TopicOverrides struct {
+	ReplicationFactor ReplicationOverride `json:"replication_factor"`
+	Partitions        PartitionOverride   `json:"partitions"`
+	DenyConfigs       []string            `json:"deny_configs,omitempty"`  // globs or re:<regex>, added to the default denylist
+	AllowConfigs      []string            `json:"allow_configs,omitempty"` // removed from the denylist
+	Configs           map[string]string   `json:"configs,omitempty"`       // forced values; "" removes the config
+
+	deny  []*regexp.Regexp
+	allow []*regexp.Regexp
+}
+
+// ReplicationOverride sets, scales or caps the replication factor, in that order
+type ReplicationOverride struct {
+	Set   int     `json:"set,omitempty"`
+	Scale float64 `json:"scale,omitempty"` // rounded up, at least 1
+	Max   int     `json:"max,omitempty"`
+}
+
+// PartitionOverride sets or multiplies the partition count, then applies Min
+type PartitionOverride struct {
+	Set      int `json:"set,omitempty"`
+	Multiply int `json:"multiply,omitempty"`
+	Min      int `json:"min,omitempty"`
+}
+
+// loadTopicOverrides reads an overrides file. An empty filename gives the default denylist only.
+func loadTopicOverrides(filename string) (*TopicOverrides, error) {
+	overrides := &TopicOverrides{}
+	if filename != "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read topic overrides: %w", err)
+		}
+		if err := json.Unmarshal(data, overrides); err != nil {
+			return nil, fmt.Errorf("failed to parse topic overrides %s: %w", filename, err)
+		}
+	}
+
+	for _, pattern := range append(append([]string(nil), defaultDeniedConfigs...), overrides.DenyConfigs...) {
+		re, err := compileNamePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		overrides.deny = append(overrides.deny, re)
+	}
+	for _, pattern := range overrides.AllowConfigs {
+		re, err := compileNamePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		overrides.allow = append(overrides.allow, re)
+	}
+	return overrides, nil
+}
+
+// denied reports whether a config is on the denylist and not allowed
+func (o *TopicOverrides) denied(config string) bool {
+	for _, re := range o.allow {
+		if re.MatchString(config) {
+			return false
+		}
+	}
+	for _, re := range o.deny {
+		if re.MatchString(config) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the topic as it should be created on the target, with a note for every change.
+// A nil TopicOverrides returns the topic unchanged.
+func (o *TopicOverrides) apply(topic TopicInfo) (TopicInfo, []string) {
+	if o == nil {
+		return topic, nil
+	}
+	var notes []string
+	target := topic
+
+	rf := topic.ReplicationFactor
+	if o.ReplicationFactor.Set > 0 {
+		rf = o.ReplicationFactor.Set
+	} else if o.ReplicationFactor.Scale > 0 {
+		rf = int(math.Ceil(float64(rf) * o.ReplicationFactor.Scale))
+	}
+	if o.ReplicationFactor.Max > 0 && rf > o.ReplicationFactor.Max {
+		rf = o.ReplicationFactor.Max
+	}
+	if rf < 1 {
+		rf = 1
+	}
+	if rf != topic.ReplicationFactor {
+		notes = append(notes, fmt.Sprintf("replication factor %d -> %d", topic.ReplicationFactor, rf))
+		target.ReplicationFactor = rf
+	}
+
+	partitions := topic.Partitions
+	if o.Partitions.Set > 0 {
+		partitions = o.Partitions.Set
+	} else if o.Partitions.Multiply > 0 {
+		partitions *= o.Partitions.Multiply
+	}
+	if partitions < o.Partitions.Min {
+		partitions = o.Partitions.Min
+	}
+	if partitions != topic.Partitions {
+		notes = append(notes, fmt.Sprintf("partitions %d -> %d", topic.Partitions, partitions))
+		target.Partitions = partitions
+	}
+
+	target.Configs = make(map[string]string, len(topic.Configs))
+	names := make([]string, 0, len(topic.Configs))
+	for name := range topic.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if o.denied(name) {
+			notes = append(notes, fmt.Sprintf("dropped %s=%s", name, topic.Configs[name]))
+			continue
+		}
+		target.Configs[name] = topic.Configs[name]
+	}
+
+	forced := make([]string, 0, len(o.Configs))
+	for name := range o.Configs {
+		forced = append(forced, name)
+	}
+	sort.Strings(forced)
+	for _, name := range forced {
+		value := o.Configs[name]
+		if value == "" {
+			if _, ok := target.Configs[name]; ok {
+				delete(target.Configs, name)
+				notes = append(notes, fmt.Sprintf("removed %s", name))
+			}
+			continue
+		}
+		if previous, ok := target.Configs[name]; !ok {
+			notes = append(notes, fmt.Sprintf("%s=%s (was default)", name, value))
+		} else if previous != value {
+			notes = append(notes, fmt.Sprintf("%s=%s (was %s)", name, value, previous))
+		}
+		target.Configs[name] = value
+	}
+
+	// A lower replication factor must not leave min.insync.replicas unreachable
+	_, forcedMinISR := o.Configs["min.insync.replicas"]
+	if minISR, err := strconv.Atoi(target.Configs["min.insync.replicas"]); err == nil && !forcedMinISR && rf < topic.ReplicationFactor && minISR > rf {
+		adjusted := rf - 1
+		if adjusted < 1 {
+			adjusted = 1
+		}
+		if adjusted != minISR {
+			target.Configs["min.insync.replicas"] = strconv.Itoa(adjusted)
+			notes = append(notes, fmt.Sprintf("min.insync.replicas %d -> %d for replication factor %d", minISR, adjusted, rf))
+		}
+	}
+
+	return target, notes
+}
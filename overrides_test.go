@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestApplyOverridesMinISR(t *testing.T) {
+	tests := []struct {
+		rf, minISR, maxRF int
+		want              string
+	}{
+		{3, 2, 2, "2"}, // reachable with the new replication factor
+		{3, 3, 2, "1"}, // exceeds it
+		{5, 4, 2, "1"},
+		{3, 2, 1, "1"},
+		{3, 2, 3, "2"}, // replication factor unchanged
+	}
+	for _, tt := range tests {
+		overrides, err := loadTopicOverrides("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		overrides.ReplicationFactor.Max = tt.maxRF
+
+		topic := TopicInfo{
+			Name:              "orders",
+			ReplicationFactor: tt.rf,
+			Configs:           map[string]string{"min.insync.replicas": strconv.Itoa(tt.minISR)},
+		}
+		target, _ := overrides.apply(topic)
+		if got := target.Configs["min.insync.replicas"]; got != tt.want {
+			t.Errorf("rf %d -> %d, min.insync.replicas %d: got %s, want %s", tt.rf, tt.maxRF, tt.minISR, got, tt.want)
+		}
+	}
+}
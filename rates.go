@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// hotPartitionFactor flags partitions producing at least this multiple of their topic's mean rate
+const hotPartitionFactor = 2.0
+
+// ProduceRate is the produce rate of a topic, measured from high watermark samples
+type ProduceRate struct {
+	MessagesPerSec     float64         `json:"messages_per_sec"`
+	PeakMessagesPerSec float64         `json:"peak_messages_per_sec"`
+	BytesPerSec        float64         `json:"bytes_per_sec,omitempty"`
+	BytesSource        string          `json:"bytes_source,omitempty"` // "topic-sizes" (on disk) or "sample" (uncompressed)
+	WindowSeconds      float64         `json:"window_seconds"`
+	Partitions         []PartitionRate `json:"partitions,omitempty"`
+	HotPartitions      []int32         `json:"hot_partitions,omitempty"`
+}
+
+// PartitionRate is the produce rate of one partition
+type PartitionRate struct {
+	Partition      int32   `json:"partition"`
+	MessagesPerSec float64 `json:"messages_per_sec"`
+}
+
+// watermarkSample is the high watermark of every partition at one point in time
+type watermarkSample struct {
+	offsets map[string]map[int32]int64
+	times   map[string]map[int32]time.Time
+}
+
+// sampleHighWatermarks fetches the high watermarks of all partitions with one ListOffsets
+// request per leader, so the partitions of a broker are sampled at the same moment
+func sampleHighWatermarks(client sarama.Client, topics []TopicInfo) (*watermarkSample, error) {
+	requests := make(map[*sarama.Broker]*sarama.OffsetRequest)
+	for _, topic := range topics {
+		for partition := int32(0); partition < int32(topic.Partitions); partition++ {
+			leader, err := client.Leader(topic.Name, partition)
+			if err != nil {
+				log.Printf("Warning: Could not find leader for topic %s partition %d: %v", topic.Name, partition, err)
+				continue
+			}
+			req, ok := requests[leader]
+			if !ok {
+				req = &sarama.OffsetRequest{Version: 1}
+				requests[leader] = req
+			}
+			req.AddBlock(topic.Name, partition, sarama.OffsetNewest, 1)
+		}
+	}
+
+	sample := &watermarkSample{
+		offsets: make(map[string]map[int32]int64),
+		times:   make(map[string]map[int32]time.Time),
+	}
+	for broker, req := range requests {
+		resp, err := broker.GetAvailableOffsets(req)
+		if err != nil {
+			return nil, fmt.Errorf("broker %d: %v", broker.ID(), err)
+		}
+		now := time.Now()
+		for topic, partitions := range resp.Blocks {
+			for partition, block := range partitions {
+				if block.Err != sarama.ErrNoError {
+					continue
+				}
+				if sample.offsets[topic] == nil {
+					sample.offsets[topic] = make(map[int32]int64)
+					sample.times[topic] = make(map[int32]time.Time)
+				}
+				sample.offsets[topic][partition] = block.Offset
+				sample.times[topic][partition] = now
+			}
+		}
+	}
+	return sample, nil
+}
+
+// measureProduceRates samples the high watermarks count times over window and sets the
+// produce rate of every topic. Bytes/sec come from sizes when given (on-disk bytes per message),
+// otherwise from the message sample (uncompressed key and value sizes).
+func measureProduceRates(client sarama.Client, info *KafkaClusterInfo, window time.Duration, count int, sizes *TopicSizesReport) error {
+	if count < 2 {
+		count = 2
+	}
+	interval := window / time.Duration(count-1)
+
+	var samples []*watermarkSample
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		sample, err := sampleHighWatermarks(client, info.Topics)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, sample)
+	}
+
+	sizeByTopic := make(map[string]int64)
+	if sizes != nil {
+		for _, topic := range sizes.Topics {
+			sizeByTopic[topic.Topic] = topic.TotalSize
+		}
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	for i := range info.Topics {
+		topic := &info.Topics[i]
+		rate := &ProduceRate{}
+
+		var totalRate float64
+		for partition, end := range last.offsets[topic.Name] {
+			start, ok := first.offsets[topic.Name][partition]
+			if !ok {
+				continue
+			}
+			elapsed := last.times[topic.Name][partition].Sub(first.times[topic.Name][partition]).Seconds()
+			if elapsed <= 0 {
+				continue
+			}
+			partitionRate := float64(end-start) / elapsed
+			rate.Partitions = append(rate.Partitions, PartitionRate{Partition: partition, MessagesPerSec: partitionRate})
+			totalRate += partitionRate
+			if elapsed > rate.WindowSeconds {
+				rate.WindowSeconds = elapsed
+			}
+		}
+		if len(rate.Partitions) == 0 {
+			continue
+		}
+		sort.Slice(rate.Partitions, func(a, b int) bool { return rate.Partitions[a].Partition < rate.Partitions[b].Partition })
+		rate.MessagesPerSec = totalRate
+		rate.PeakMessagesPerSec = peakRate(topic.Name, samples)
+
+		mean := totalRate / float64(len(rate.Partitions))
+		if len(rate.Partitions) > 1 && mean > 0 {
+			for _, p := range rate.Partitions {
+				if p.MessagesPerSec >= hotPartitionFactor*mean {
+					rate.HotPartitions = append(rate.HotPartitions, p.Partition)
+				}
+			}
+		}
+
+		// Bytes per message: retained bytes of one replica per retained message, or sampled record size
+		if size := sizeByTopic[topic.Name]; size > 0 && topic.TotalMessages > 0 && topic.ReplicationFactor > 0 {
+			bytesPerMessage := float64(size) / float64(topic.ReplicationFactor) / float64(topic.TotalMessages)
+			rate.BytesPerSec = totalRate * bytesPerMessage
+			rate.BytesSource = "topic-sizes"
+		} else if topic.Sample != nil && topic.Sample.Records > 0 {
+			rate.BytesPerSec = totalRate * (topic.Sample.KeySize.Mean + topic.Sample.ValueSize.Mean)
+			rate.BytesSource = "sample"
+		}
+
+		topic.ProduceRate = rate
+	}
+	return nil
+}
+
+// peakRate returns the highest topic rate between two consecutive samples
+func peakRate(topic string, samples []*watermarkSample) float64 {
+	var peak float64
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		var messages int64
+		var elapsed float64
+		for partition, end := range cur.offsets[topic] {
+			start, ok := prev.offsets[topic][partition]
+			if !ok {
+				continue
+			}
+			messages += end - start
+			if d := cur.times[topic][partition].Sub(prev.times[topic][partition]).Seconds(); d > elapsed {
+				elapsed = d
+			}
+		}
+		if elapsed > 0 && float64(messages)/elapsed > peak {
+			peak = float64(messages) / elapsed
+		}
+	}
+	return peak
+}
+
+// formatRate formats a per-second rate with a precision that suits its magnitude
+func formatRate(rate float64) string {
+	switch {
+	case rate >= 1000:
+		return formatCompact(int64(rate + 0.5))
+	case rate >= 10:
+		return fmt.Sprintf("%.0f", rate)
+	case rate > 0:
+		return fmt.Sprintf("%.2f", rate)
+	}
+	return "0"
+}
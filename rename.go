@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validTopicName matches the names Kafka accepts
+var validTopicName = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// TopicRenames maps source topic names to target names, e.g. for MirrorMaker 2's "source." prefix.
+// Exact names are tried first, then the regex rules in order, then the longest matching prefix.
+type TopicRenames struct {
+	Topics   map[string]string `json:"topics,omitempty"`   // exact source name to target name
+	Regex    []RegexRename     `json:"regex,omitempty"`    // first match wins
+	Prefixes map[string]string `json:"prefixes,omitempty"` // source prefix to target prefix; "" prefixes every topic
+
+	patterns []*regexp.Regexp
+}
+
+// RegexRename rewrites names matching Pattern; Replacement can use $1 or ${name}
+type RegexRename struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// loadTopicRenames reads a rename mapping file and compiles its regex rules
+func loadTopicRenames(filename string) (*TopicRenames, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rename map: %w", err)
+	}
+
+	var renames TopicRenames
+	if err := json.Unmarshal(data, &renames); err != nil {
+		return nil, fmt.Errorf("failed to parse rename map %s: %w", filename, err)
+	}
+	for _, rule := range renames.Regex {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename pattern %q: %v", rule.Pattern, err)
+		}
+		renames.patterns = append(renames.patterns, re)
+	}
+	return &renames, nil
+}
+
+// Rename returns the target name of a topic. A nil TopicRenames keeps all names.
+func (r *TopicRenames) Rename(topic string) string {
+	if r == nil {
+		return topic
+	}
+	if target, ok := r.Topics[topic]; ok {
+		return target
+	}
+	for i, re := range r.patterns {
+		if re.MatchString(topic) {
+			return re.ReplaceAllString(topic, r.Regex[i].Replacement)
+		}
+	}
+
+	longest := -1
+	var target string
+	for from, to := range r.Prefixes {
+		if strings.HasPrefix(topic, from) && len(from) > longest {
+			longest = len(from)
+			target = to + topic[len(from):]
+		}
+	}
+	if longest >= 0 {
+		return target
+	}
+	return topic
+}
+
+// validate checks that every renamed topic is a valid Kafka topic name and that no two
+// source topics map to the same target
+func (r *TopicRenames) validate(topics []string) error {
+	if r == nil {
+		return nil
+	}
+
+	sorted := append([]string(nil), topics...)
+	sort.Strings(sorted)
+	sources := make(map[string]string)
+	for _, topic := range sorted {
+		target := r.Rename(topic)
+		if !validTopicName.MatchString(target) {
+			return fmt.Errorf("topic %s is renamed to invalid name %q", topic, target)
+		}
+		if other, ok := sources[target]; ok {
+			return fmt.Errorf("topics %s and %s are both renamed to %s", other, topic, target)
+		}
+		sources[target] = topic
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRenameMap(t *testing.T, content string) *TopicRenames {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "renames.json")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	renames, err := loadTopicRenames(filename)
+	if err != nil {
+		t.Fatalf("loadTopicRenames: %v", err)
+	}
+	return renames
+}
+
+func TestTopicRenamesRename(t *testing.T) {
+	var none *TopicRenames
+	if got := none.Rename("orders"); got != "orders" {
+		t.Errorf("nil renames: got %s", got)
+	}
+	if got := (&TopicRenames{}).Rename("orders"); got != "orders" {
+		t.Errorf("empty renames: got %s", got)
+	}
+
+	renames := writeRenameMap(t, `{
+		"topics": {"source.orders": "orders-v2", "a": "b", "b": "c"},
+		"regex": [{"pattern": "^source\\.(.*)-events$", "replacement": "events.$1"}],
+		"prefixes": {"source.": "", "source.legacy.": "archive."}
+	}`)
+	tests := map[string]string{
+		"source.orders":         "orders-v2",      // exact name before the prefix
+		"source.billing-events": "events.billing", // regex before the prefix
+		"source.payments":       "payments",
+		"source.legacy.audit":   "archive.audit", // longest prefix
+		"other":                 "other",
+		"a":                     "b", // renames are not chained
+		"b":                     "c",
+	}
+	for topic, want := range tests {
+		if got := renames.Rename(topic); got != want {
+			t.Errorf("Rename(%s) = %s, want %s", topic, got, want)
+		}
+	}
+}
+
+func TestTopicRenamesValidate(t *testing.T) {
+	var none *TopicRenames
+	if err := none.validate([]string{"orders", "source.orders"}); err != nil {
+		t.Errorf("nil renames: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		renames string
+		topics  []string
+		err     string // empty if valid
+	}{
+		{
+			name:    "renamed onto an existing topic",
+			renames: `{"prefixes": {"source.": ""}}`,
+			topics:  []string{"orders", "source.orders"},
+			err:     "orders and source.orders are both renamed to orders",
+		},
+		{
+			name:    "two topics onto one",
+			renames: `{"topics": {"orders-a": "orders", "orders-b": "orders"}}`,
+			topics:  []string{"orders-b", "orders-a"},
+			err:     "orders-a and orders-b are both renamed to orders",
+		},
+		{
+			name:    "chained rename",
+			renames: `{"topics": {"a": "b", "b": "c"}}`,
+			topics:  []string{"a", "b"},
+		},
+		{
+			name:    "chained rename onto an unrenamed topic",
+			renames: `{"topics": {"a": "b"}}`,
+			topics:  []string{"a", "b"},
+			err:     "a and b are both renamed to b",
+		},
+		{
+			name:    "swap",
+			renames: `{"topics": {"a": "b", "b": "a"}}`,
+			topics:  []string{"a", "b"},
+		},
+		{
+			name:    "invalid character",
+			renames: `{"regex": [{"pattern": "^(.*)$", "replacement": "dr/$1"}]}`,
+			topics:  []string{"orders"},
+			err:     `invalid name "dr/orders"`,
+		},
+		{
+			name:    "empty name",
+			renames: `{"topics": {"orders": ""}}`,
+			topics:  []string{"orders"},
+			err:     `invalid name ""`,
+		},
+		{
+			name:    "too long",
+			renames: `{"prefixes": {"": "` + strings.Repeat("x", 245) + `."}}`,
+			topics:  []string{"orders"},
+			err:     "invalid name",
+		},
+	}
+	for _, tt := range tests {
+		err := writeRenameMap(t, tt.renames).validate(tt.topics)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("%s: expected an error", tt.name)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: error %q does not contain %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestLoadTopicRenamesInvalidPattern(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "renames.json")
+	if err := os.WriteFile(filename, []byte(`{"regex": [{"pattern": "(", "replacement": ""}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTopicRenames(filename); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// templateExtensions are stripped from template file names to get the output name,
+// e.g. "summary.md.tmpl" renders to "summary.md"
+var templateExtensions = []string{".tmpl", ".gotmpl", ".tpl"}
+
+// reportTemplateSet is the common part of html/template and text/template
+type reportTemplateSet interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// templateFuncs returns the helpers available to report templates. For HTML output,
+// getURPCard returns trusted markup; other outputs get it as plain text.
+func templateFuncs(html bool) map[string]interface{} {
+	funcs := map[string]interface{}{
+		"formatNumber":    formatNumber,
+		"formatBytes":     formatBytes,
+		"formatCompact":   formatCompact,
+		"formatRate":      formatRate,
+		"formatPercent":   formatPercent,
+		"formatAge":       formatAge,
+		"formatRetention": formatRetention,
+		"getURPCard":      getURPCard,
+		"isInternalTopic": func(name string) bool { return strings.HasPrefix(name, "__") },
+		"configList":      sortedConfigs,
+		"join":            strings.Join,
+		"markdownEscape":  markdownEscape,
+	}
+	if html {
+		funcs["getURPCard"] = func(urps int) htmltemplate.HTML { return htmltemplate.HTML(getURPCard(urps)) }
+	}
+	return funcs
+}
+
+// isHTMLOutput reports whether a rendered file needs contextual HTML escaping
+func isHTMLOutput(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm", ".xhtml":
+		return true
+	}
+	return false
+}
+
+// templateOutputName strips a template extension from name
+func templateOutputName(name string) string {
+	for _, ext := range templateExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// parseReportTemplates parses files into a template set named after the first file.
+// HTML output uses html/template for escaping, everything else text/template.
+func parseReportTemplates(html bool, files ...string) (reportTemplateSet, error) {
+	name := filepath.Base(files[0])
+	if html {
+		return htmltemplate.New(name).Funcs(templateFuncs(true)).ParseFiles(files...)
+	}
+	return texttemplate.New(name).Funcs(templateFuncs(false)).ParseFiles(files...)
+}
+
+// renderReportTemplate renders the template in files[0] (with the other files as partials) to output
+func renderReportTemplate(info *KafkaClusterInfo, output string, files ...string) error {
+	for _, file := range files {
+		if sameFile(file, output) {
+			return fmt.Errorf("output %s would overwrite template %s", output, file)
+		}
+	}
+
+	tmpl, err := parseReportTemplates(isHTMLOutput(output), files...)
+	if err != nil {
+		return fmt.Errorf("error parsing template: %v", err)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	if err := tmpl.ExecuteTemplate(f, filepath.Base(files[0]), newHTMLReportData(info)); err != nil {
+		return fmt.Errorf("error rendering %s: %v", files[0], err)
+	}
+	return f.Close()
+}
+
+// renderCustomTemplates renders user templates instead of the built-in HTML report.
+//
+// A single template file is rendered to output. For a directory, every file is rendered
+// into the output directory under its own name minus .tmpl/.gotmpl/.tpl; files starting
+// with "_" are partials that are available to all templates via {{template "_name"}}
+// but are not rendered themselves. Returns the files written.
+func renderCustomTemplates(info *KafkaClusterInfo, path, output string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stat.IsDir() {
+		if err := renderReportTemplate(info, output, path); err != nil {
+			return nil, err
+		}
+		return []string{output}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates, partials []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), "_") {
+			partials = append(partials, filepath.Join(path, entry.Name()))
+		} else {
+			templates = append(templates, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no templates found in %s", path)
+	}
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, tmpl := range templates {
+		filename := filepath.Join(output, templateOutputName(filepath.Base(tmpl)))
+		if err := renderReportTemplate(info, filename, append([]string{tmpl}, partials...)...); err != nil {
+			return written, err
+		}
+		written = append(written, filename)
+	}
+	return written, nil
+}
+
+// sameFile reports whether a and b refer to the same existing file
+func sameFile(a, b string) bool {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(sa, sb)
+}
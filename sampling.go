@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IBM/sarama"
+)
+
+// Payload formats detected by detectPayloadFormat
+const (
+	PayloadEmpty      = "empty"
+	PayloadJSON       = "json"
+	PayloadConfluent  = "confluent-framed" // Confluent wire format (magic byte 0 + 4-byte schema ID), type unknown
+	PayloadAvro       = "avro"             // Confluent wire format with an AVRO schema in the registry
+	PayloadJSONSchema = "json-schema"      // Confluent wire format followed by JSON
+	PayloadProtobuf   = "protobuf"
+	PayloadText       = "text"
+	PayloadBinary     = "binary"
+)
+
+// maxSampleFetchBytes caps a single fetch while sampling
+const maxSampleFetchBytes = 1024 * 1024
+
+// maxSampleHeaderKeys caps the distinct header keys reported per topic
+const maxSampleHeaderKeys = 50
+
+// MessageSample describes the last records of a topic
+type MessageSample struct {
+	Records         int            `json:"sampled_records"`
+	Bytes           int64          `json:"sampled_bytes"`
+	KeySize         SizeStats      `json:"key_size"`
+	ValueSize       SizeStats      `json:"value_size"`
+	NullKeys        int            `json:"null_keys,omitempty"`
+	Tombstones      int            `json:"tombstones,omitempty"`
+	KeyFormat       string         `json:"key_format,omitempty"`
+	ValueFormat     string         `json:"value_format,omitempty"`
+	ValueFormats    map[string]int `json:"value_formats,omitempty"`
+	SchemaIDs       []int32        `json:"schema_ids,omitempty"`
+	HeaderKeys      []string       `json:"header_keys,omitempty"`
+	Compression     []string       `json:"compression,omitempty"`
+	BudgetExhausted bool           `json:"budget_exhausted,omitempty"`
+}
+
+// SizeStats is a size distribution in bytes
+type SizeStats struct {
+	Min  int     `json:"min"`
+	Max  int     `json:"max"`
+	Mean float64 `json:"mean"`
+	P50  int     `json:"p50"`
+	P95  int     `json:"p95"`
+	P99  int     `json:"p99"`
+}
+
+// fetchedRecord is a record read with a raw fetch request, decompressed
+type fetchedRecord struct {
+	Offset     int64
+	Timestamp  time.Time
+	Key        []byte
+	Value      []byte
+	HeaderKeys []string
+	Codec      sarama.CompressionCodec
+}
+
+func (r fetchedRecord) size() int64 {
+	size := int64(len(r.Key) + len(r.Value))
+	for _, k := range r.HeaderKeys {
+		size += int64(len(k))
+	}
+	return size
+}
+
+// fetchResult is what fetchRecords read
+type fetchResult struct {
+	Records []fetchedRecord
+	Bytes   int64 // size of every record received, including those outside the range
+	Next    int64 // offset to continue from; the start offset if nothing could be read
+}
+
+// fetchRecords reads up to maxRecords records of a partition from offset start, stopping before
+// end, with plain fetch requests to the leader. This is strictly read-only: no consumer group
+// is joined and no offsets are committed. Only committed records are returned: transaction
+// markers and records of aborted transactions are skipped, and reading stops at the last stable
+// offset. maxBytes limits the bytes fetched across all requests, though the broker always
+// returns at least one batch.
+func fetchRecords(client sarama.Client, topic string, partition int32, start, end int64, maxRecords int, maxBytes int32) (fetchResult, error) {
+	result := fetchResult{Next: start}
+	leader, err := client.Leader(topic, partition)
+	if err != nil {
+		return result, err
+	}
+
+	for attempt := 0; attempt < 10 && result.Next < end && len(result.Records) < maxRecords && result.Bytes < int64(maxBytes); attempt++ {
+		offset := result.Next
+		requestBytes := maxBytes - int32(result.Bytes)
+		req := &sarama.FetchRequest{
+			Version:     4,
+			MaxWaitTime: 500,
+			MinBytes:    1,
+			MaxBytes:    requestBytes,
+			Isolation:   sarama.ReadCommitted,
+		}
+		req.AddBlock(topic, partition, offset, requestBytes, -1)
+
+		resp, err := leader.Fetch(req)
+		if err != nil {
+			return result, err
+		}
+		block := resp.GetBlock(topic, partition)
+		if block == nil {
+			break
+		}
+		if block.Err != sarama.ErrNoError {
+			return result, block.Err
+		}
+
+		// Aborted transactions in offset order. A producer's records are skipped from the
+		// transaction's first offset up to its abort marker.
+		aborted := append([]*sarama.AbortedTransaction(nil), block.AbortedTransactions...)
+		sort.Slice(aborted, func(i, j int) bool { return aborted[i].FirstOffset < aborted[j].FirstOffset })
+		abortedProducers := make(map[int64]bool)
+
+		// Once the range or maxRecords is reached, the rest is only counted
+		done := false
+		for _, set := range block.RecordsSet {
+			if batch := set.RecordBatch; batch != nil {
+				last := batch.FirstOffset + int64(batch.LastOffsetDelta)
+				for len(aborted) > 0 && aborted[0].FirstOffset <= last {
+					abortedProducers[aborted[0].ProducerID] = true
+					aborted = aborted[1:]
+				}
+				skip := batch.Control || (batch.IsTransactional && abortedProducers[batch.ProducerID])
+				if isAbortMarker(batch) {
+					delete(abortedProducers, batch.ProducerID)
+				}
+				if skip {
+					for _, r := range batch.Records {
+						result.Bytes += int64(len(r.Key) + len(r.Value))
+					}
+					if !done && last >= result.Next {
+						result.Next = last + 1
+						if result.Next > end {
+							result.Next = end
+						}
+					}
+					continue
+				}
+			}
+
+			for _, rec := range decodeRecords(set) {
+				result.Bytes += rec.size()
+				// Batches can start before the requested offset
+				if done || rec.Offset < result.Next {
+					continue
+				}
+				if rec.Offset >= end || len(result.Records) >= maxRecords {
+					done = true
+					continue
+				}
+				result.Records = append(result.Records, rec)
+				result.Next = rec.Offset + 1
+			}
+		}
+
+		// No progress: empty response, the last stable offset, or a record larger than maxBytes
+		if result.Next == offset {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// isAbortMarker reports whether batch holds the ABORT marker of a transaction. The control
+// record key is a version and a type, 0 for ABORT and 1 for COMMIT.
+func isAbortMarker(batch *sarama.RecordBatch) bool {
+	if !batch.Control || len(batch.Records) == 0 || len(batch.Records[0].Key) < 4 {
+		return false
+	}
+	return binary.BigEndian.Uint16(batch.Records[0].Key[2:4]) == 0
+}
+
+// decodeRecords flattens a record batch (v2) or legacy message set
+func decodeRecords(set *sarama.Records) []fetchedRecord {
+	var records []fetchedRecord
+
+	if batch := set.RecordBatch; batch != nil {
+		// Transaction markers are not user data
+		if batch.Control {
+			return nil
+		}
+		for _, r := range batch.Records {
+			rec := fetchedRecord{
+				Offset:    batch.FirstOffset + r.OffsetDelta,
+				Timestamp: batch.FirstTimestamp.Add(r.TimestampDelta),
+				Key:       r.Key,
+				Value:     r.Value,
+				Codec:     batch.Codec,
+			}
+			if batch.LogAppendTime {
+				rec.Timestamp = batch.MaxTimestamp
+			}
+			for _, h := range r.Headers {
+				rec.HeaderKeys = append(rec.HeaderKeys, string(h.Key))
+			}
+			records = append(records, rec)
+		}
+	}
+
+	if set.MsgSet != nil {
+		for _, block := range set.MsgSet.Messages {
+			codec := block.Msg.Codec
+			for _, msg := range block.Messages() {
+				records = append(records, fetchedRecord{
+					Offset:    msg.Offset,
+					Timestamp: msg.Msg.Timestamp,
+					Key:       msg.Msg.Key,
+					Value:     msg.Msg.Value,
+					Codec:     codec,
+				})
+			}
+		}
+	}
+
+	return records
+}
+
+// sampleBudget is the number of bytes left for sampling across all topics
+type sampleBudget struct {
+	remaining int64
+}
+
+// sampleTopic fetches the last perPartition records of every partition and summarizes them
+func sampleTopic(client sarama.Client, topic string, partitions int, perPartition int, budget *sampleBudget) (*MessageSample, error) {
+	var records []fetchedRecord
+	sample := &MessageSample{}
+
+	for partition := int32(0); partition < int32(partitions); partition++ {
+		if budget.remaining <= 0 {
+			sample.BudgetExhausted = true
+			break
+		}
+
+		newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+		oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		start := newest - int64(perPartition)
+		if start < oldest {
+			start = oldest
+		}
+		if start >= newest {
+			continue
+		}
+
+		maxBytes := int32(maxSampleFetchBytes)
+		if budget.remaining < maxSampleFetchBytes {
+			maxBytes = int32(budget.remaining)
+		}
+		fetched, err := fetchRecords(client, topic, partition, start, newest, perPartition, maxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("partition %d: %v", partition, err)
+		}
+		budget.remaining -= fetched.Bytes
+		records = append(records, fetched.Records...)
+	}
+
+	summarizeSample(sample, records)
+	return sample, nil
+}
+
+// summarizeSample computes size distributions, formats, header keys and codecs
+func summarizeSample(sample *MessageSample, records []fetchedRecord) {
+	var keySizes, valueSizes []int
+	keyFormats := make(map[string]int)
+	valueFormats := make(map[string]int)
+	schemaIDs := make(map[int32]bool)
+	headerKeys := make(map[string]bool)
+	codecs := make(map[string]bool)
+
+	for _, rec := range records {
+		sample.Records++
+		sample.Bytes += rec.size()
+		keySizes = append(keySizes, len(rec.Key))
+		valueSizes = append(valueSizes, len(rec.Value))
+		codecs[rec.Codec.String()] = true
+
+		if rec.Key == nil {
+			sample.NullKeys++
+		} else {
+			format, _ := detectPayloadFormat(rec.Key)
+			keyFormats[format]++
+		}
+		if rec.Value == nil {
+			sample.Tombstones++
+		} else {
+			format, schemaID := detectPayloadFormat(rec.Value)
+			valueFormats[format]++
+			if schemaID >= 0 {
+				schemaIDs[schemaID] = true
+			}
+		}
+		for _, k := range rec.HeaderKeys {
+			if len(headerKeys) < maxSampleHeaderKeys {
+				headerKeys[k] = true
+			}
+		}
+	}
+
+	sample.KeySize = sizeStats(keySizes)
+	sample.ValueSize = sizeStats(valueSizes)
+	sample.KeyFormat = dominantFormat(keyFormats)
+	sample.ValueFormat = dominantFormat(valueFormats)
+	if len(valueFormats) > 0 {
+		sample.ValueFormats = valueFormats
+	}
+	for id := range schemaIDs {
+		sample.SchemaIDs = append(sample.SchemaIDs, id)
+	}
+	sort.Slice(sample.SchemaIDs, func(i, j int) bool { return sample.SchemaIDs[i] < sample.SchemaIDs[j] })
+	for k := range headerKeys {
+		sample.HeaderKeys = append(sample.HeaderKeys, k)
+	}
+	sort.Strings(sample.HeaderKeys)
+	for c := range codecs {
+		sample.Compression = append(sample.Compression, c)
+	}
+	sort.Strings(sample.Compression)
+}
+
+// dominantFormat returns the most common format, or "mixed" if none covers 90% of the records
+func dominantFormat(formats map[string]int) string {
+	var best string
+	var bestCount, total int
+	for format, count := range formats {
+		total += count
+		if count > bestCount || (count == bestCount && format < best) {
+			best, bestCount = format, count
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	if bestCount*10 < total*9 {
+		return "mixed"
+	}
+	return best
+}
+
+func sizeStats(sizes []int) SizeStats {
+	if len(sizes) == 0 {
+		return SizeStats{}
+	}
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+
+	var sum int64
+	for _, s := range sorted {
+		sum += int64(s)
+	}
+	percentile := func(p float64) int {
+		return sorted[int(p*float64(len(sorted)-1)+0.5)]
+	}
+	return SizeStats{
+		Min:  sorted[0],
+		Max:  sorted[len(sorted)-1],
+		Mean: float64(sum) / float64(len(sorted)),
+		P50:  percentile(0.50),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+	}
+}
+
+// detectPayloadFormat guesses the serialization of a key or value. For the Confluent wire
+// format it also returns the schema ID, otherwise -1.
+func detectPayloadFormat(data []byte) (string, int32) {
+	if len(data) == 0 {
+		return PayloadEmpty, -1
+	}
+
+	// Confluent Schema Registry serializers: magic byte 0, 4-byte big-endian schema ID, payload.
+	// Avro and Protobuf use the same prefix; only the registry knows the actual type.
+	if data[0] == 0 && len(data) >= 5 {
+		schemaID := int32(binary.BigEndian.Uint32(data[1:5]))
+		if schemaID > 0 {
+			if isJSONDocument(data[5:]) {
+				return PayloadJSONSchema, schemaID
+			}
+			return PayloadConfluent, schemaID
+		}
+	}
+
+	if isJSONDocument(data) {
+		return PayloadJSON, -1
+	}
+	if isPrintableText(data) {
+		return PayloadText, -1
+	}
+	if isProtobufMessage(data) {
+		return PayloadProtobuf, -1
+	}
+	return PayloadBinary, -1
+}
+
+// resolveSchemaFormat replaces confluent-framed in a sample's value formats with the payload
+// format of the registry schema type, if all of the sample's schema IDs have the same type
+func resolveSchemaFormat(sample *MessageSample, schemaTypes map[int32]string) {
+	var format string
+	for _, id := range sample.SchemaIDs {
+		f := schemaPayloadFormat(schemaTypes[id])
+		if f == "" || (format != "" && f != format) {
+			return
+		}
+		format = f
+	}
+	count, ok := sample.ValueFormats[PayloadConfluent]
+	if format == "" || !ok {
+		return
+	}
+	delete(sample.ValueFormats, PayloadConfluent)
+	sample.ValueFormats[format] += count
+	sample.ValueFormat = dominantFormat(sample.ValueFormats)
+}
+
+// schemaPayloadFormat maps a registry schema type to a payload format
+func schemaPayloadFormat(schemaType string) string {
+	switch schemaType {
+	case "AVRO":
+		return PayloadAvro
+	case "PROTOBUF":
+		return PayloadProtobuf
+	case "JSON":
+		return PayloadJSONSchema
+	}
+	return ""
+}
+
+func isJSONDocument(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	return json.Valid(trimmed)
+}
+
+// isPrintableText reports whether data is UTF-8 without control characters other than whitespace
+func isPrintableText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// isProtobufMessage reports whether data parses completely as protobuf wire format
+func isProtobufMessage(data []byte) bool {
+	fields := 0
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 || tag>>3 == 0 || tag>>3 > 536870911 {
+			return false
+		}
+		data = data[n:]
+
+		switch tag & 7 {
+		case 0: // varint
+			_, n = binary.Uvarint(data)
+			if n <= 0 {
+				return false
+			}
+			data = data[n:]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return false
+			}
+			data = data[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return false
+			}
+			data = data[n+int(length):]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return false
+			}
+			data = data[4:]
+		default: // groups are deprecated and rare
+			return false
+		}
+		fields++
+	}
+	return fields > 0
+}
+
+// sampleTopics samples every topic in info until the byte budget is used up
+func sampleTopics(client sarama.Client, info *KafkaClusterInfo, perPartition int, maxBytes int64) {
+	budget := &sampleBudget{remaining: maxBytes}
+	for i := range info.Topics {
+		topic := &info.Topics[i]
+		if topic.TotalMessages == 0 {
+			continue
+		}
+		if budget.remaining <= 0 {
+			log.Printf("Warning: sampling budget of %s exhausted, remaining topics not sampled", formatBytes(maxBytes))
+			break
+		}
+
+		sample, err := sampleTopic(client, topic.Name, topic.Partitions, perPartition, budget)
+		if err != nil {
+			log.Printf("Warning: Could not sample topic %s: %v", topic.Name, err)
+			continue
+		}
+		topic.Sample = sample
+	}
+}
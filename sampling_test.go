@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestDetectPayloadFormat(t *testing.T) {
+	framed := func(payload ...byte) []byte {
+		return append([]byte{0, 0, 0, 0, 42}, payload...)
+	}
+	tests := []struct {
+		name     string
+		data     []byte
+		format   string
+		schemaID int32
+	}{
+		{"empty", []byte{}, PayloadEmpty, -1},
+		{"json", []byte(`{"id": 1}`), PayloadJSON, -1},
+		{"text", []byte("hello"), PayloadText, -1},
+		{"protobuf", []byte{0x08, 0x96, 0x01}, PayloadProtobuf, -1},
+		{"binary", []byte{0xff, 0xfe}, PayloadBinary, -1},
+		{"framed json", framed([]byte(`{"id": 1}`)...), PayloadJSONSchema, 42},
+		// Avro and Protobuf behind the Confluent prefix look the same
+		{"framed avro", framed(0x02, 0x06, 'f', 'o', 'o'), PayloadConfluent, 42},
+		{"framed protobuf", framed(0x00, 0x08, 0x96, 0x01), PayloadConfluent, 42},
+	}
+	for _, tt := range tests {
+		format, schemaID := detectPayloadFormat(tt.data)
+		if format != tt.format || schemaID != tt.schemaID {
+			t.Errorf("%s: got %s/%d, want %s/%d", tt.name, format, schemaID, tt.format, tt.schemaID)
+		}
+	}
+}
+
+func TestResolveSchemaFormat(t *testing.T) {
+	types := map[int32]string{1: "PROTOBUF", 2: "PROTOBUF", 3: "AVRO"}
+	tests := []struct {
+		ids  []int32
+		want string
+	}{
+		{[]int32{1, 2}, PayloadProtobuf},
+		{[]int32{3}, PayloadAvro},
+		{[]int32{1, 3}, PayloadConfluent}, // types differ
+		{[]int32{4}, PayloadConfluent},    // unknown ID
+	}
+	for _, tt := range tests {
+		sample := &MessageSample{
+			ValueFormat:  PayloadConfluent,
+			ValueFormats: map[string]int{PayloadConfluent: 10},
+			SchemaIDs:    tt.ids,
+		}
+		resolveSchemaFormat(sample, types)
+		if sample.ValueFormat != tt.want || sample.ValueFormats[tt.want] != 10 {
+			t.Errorf("schema IDs %v: got %s %v, want %s", tt.ids, sample.ValueFormat, sample.ValueFormats, tt.want)
+		}
+	}
+}
+
+func TestSampleTopicBudget(t *testing.T) {
+	const topic = "orders"
+	value := sarama.ByteEncoder(bytes.Repeat([]byte("x"), 100))
+
+	// The broker returns the whole batch from offset 0, although sampling starts at 5
+	fetch := &sarama.FetchResponse{Version: 4}
+	for offset := int64(0); offset < 10; offset++ {
+		fetch.AddRecord(topic, 0, nil, value, offset)
+	}
+	fetch.SetLastOffsetDelta(topic, 0, 9)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()).
+			SetLeader(topic, 1, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(topic, 0, sarama.OffsetOldest, 0).
+			SetOffset(topic, 0, sarama.OffsetNewest, 10).
+			SetOffset(topic, 1, sarama.OffsetOldest, 0).
+			SetOffset(topic, 1, sarama.OffsetNewest, 10),
+		"FetchRequest": sarama.NewMockWrapper(fetch),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	budget := &sampleBudget{remaining: 600}
+	sample, err := sampleTopic(client, topic, 2, 5, budget)
+	if err != nil {
+		t.Fatalf("sampleTopic: %v", err)
+	}
+
+	if sample.Records != 5 {
+		t.Errorf("expected 5 sampled records, got %d", sample.Records)
+	}
+	// All ten records were transferred, so the budget is charged for them
+	if budget.remaining != 600-1000 {
+		t.Errorf("expected the budget to be charged 1000 bytes, %d remaining", budget.remaining)
+	}
+	if !sample.BudgetExhausted {
+		t.Error("expected partition 1 to be skipped")
+	}
+
+	fetches := 0
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.FetchRequest); ok {
+			fetches++
+			if req.MaxBytes > 600 {
+				t.Errorf("fetch of %d bytes exceeds the budget", req.MaxBytes)
+			}
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected one fetch, got %d", fetches)
+	}
+}
+
+func TestFetchRecordsSkipsAbortedTransactions(t *testing.T) {
+	const topic = "orders"
+	fetch := &sarama.FetchResponse{Version: 4}
+	fetch.AddRecordBatch(topic, 0, nil, sarama.StringEncoder("plain"), 0, -1, false)
+	fetch.AddRecordBatch(topic, 0, nil, sarama.StringEncoder("aborted"), 1, 7, true)
+	fetch.AddRecordBatch(topic, 0, nil, sarama.StringEncoder("committed"), 2, 8, true)
+	fetch.AddControlRecord(topic, 0, 3, 7, sarama.ControlRecordAbort)
+	fetch.AddControlRecord(topic, 0, 4, 8, sarama.ControlRecordCommit)
+	fetch.AddRecordBatch(topic, 0, nil, sarama.StringEncoder("next transaction"), 5, 7, true)
+	block := fetch.GetBlock(topic, 0)
+	block.AbortedTransactions = []*sarama.AbortedTransaction{{ProducerID: 7, FirstOffset: 1}}
+	block.HighWaterMarkOffset = 6
+	block.LastStableOffset = 6
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"FetchRequest": sarama.NewMockWrapper(fetch),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	result, err := fetchRecords(client, topic, 0, 0, 6, 100, maxSampleFetchBytes)
+	if err != nil {
+		t.Fatalf("fetchRecords: %v", err)
+	}
+	var values []string
+	for _, rec := range result.Records {
+		values = append(values, string(rec.Value))
+	}
+	if want := []string{"plain", "committed", "next transaction"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("got %q, want %q", values, want)
+	}
+	if result.Next != 6 {
+		t.Errorf("next offset %d, want 6", result.Next)
+	}
+
+	// Only markers in range: no records, but progress past them
+	result, err = fetchRecords(client, topic, 0, 3, 5, 100, maxSampleFetchBytes)
+	if err != nil {
+		t.Fatalf("fetchRecords: %v", err)
+	}
+	if len(result.Records) != 0 || result.Next != 5 {
+		t.Errorf("got %d records and next offset %d, want none and 5", len(result.Records), result.Next)
+	}
+
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.FetchRequest); ok && req.Isolation != sarama.ReadCommitted {
+			t.Errorf("fetch with isolation level %d, want read committed", req.Isolation)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeSchemaRegistry serves fixed JSON bodies by path, 404 for anything else, and rejects
+// requests without the expected basic auth credentials
+func fakeSchemaRegistry(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "sr-user" || pass != "sr-secret" {
+			t.Errorf("%s: missing or wrong credentials", r.URL.Path)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"error_code": 40401, "message": "Subject not found"}`
+		}
+		w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+var schemaRegistryRoutes = map[string]string{
+	"/subjects": `["orders-key", "orders-value", "payments-com.acme.Refund", "com.acme.Payment",
+		"com.acme.Common", "legacy-value"]`,
+	"/config":              `{"compatibilityLevel": "BACKWARD"}`,
+	"/config/orders-value": `{"compatibilityLevel": "FULL"}`,
+
+	"/subjects/orders-key/versions/latest":               `{"subject": "orders-key", "version": 1, "id": 1, "schema": "\"string\""}`,
+	"/subjects/orders-value/versions/latest":             `{"subject": "orders-value", "version": 2, "id": 3, "schemaType": "PROTOBUF", "schema": "message Order {}"}`,
+	"/subjects/payments-com.acme.Refund/versions/latest": `{"subject": "payments-com.acme.Refund", "version": 1, "id": 5, "schema": "{}"}`,
+	"/subjects/com.acme.Payment/versions/latest":         `{"subject": "com.acme.Payment", "version": 1, "id": 7, "schema": "{}"}`,
+
+	// Record name strategy subject found through a sampled schema ID
+	"/schemas/ids/7/versions": `[{"subject": "com.acme.Payment", "version": 1}]`,
+	"/schemas/ids/7":          `{"schema": "{}"}`,
+
+	// Export: orders-value v2 references com.acme.Common
+	"/subjects/orders-value/versions":      `[1, 2]`,
+	"/subjects/orders-value/versions/1":    `{"subject": "orders-value", "version": 1, "id": 2, "schemaType": "PROTOBUF", "schema": "message Order {}"}`,
+	"/subjects/orders-value/versions/2":    `{"subject": "orders-value", "version": 2, "id": 3, "schemaType": "PROTOBUF", "schema": "message Order {}", "references": [{"name": "common.proto", "subject": "com.acme.Common", "version": 1}]}`,
+	"/subjects/com.acme.Common/versions":   `[1]`,
+	"/subjects/com.acme.Common/versions/1": `{"subject": "com.acme.Common", "version": 1, "id": 4, "schemaType": "PROTOBUF", "schema": "message Common {}"}`,
+}
+
+func TestCollectSchemas(t *testing.T) {
+	server := fakeSchemaRegistry(t, schemaRegistryRoutes)
+	client, err := newSchemaRegistryClient(SchemaRegistryOptions{URL: server.URL, Username: "sr-user", Password: "sr-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &KafkaClusterInfo{Topics: []TopicInfo{
+		{Name: "orders"},
+		{Name: "payments", Sample: &MessageSample{
+			ValueFormat:  PayloadConfluent,
+			ValueFormats: map[string]int{PayloadConfluent: 3},
+			SchemaIDs:    []int32{7},
+		}},
+		{Name: "audit"},
+		{Name: "__consumer_offsets"},
+	}}
+	allTopics := []string{"orders", "payments", "audit", "__consumer_offsets"}
+
+	selected, err := collectSchemas(client, info, allTopics, nil)
+	if err != nil {
+		t.Fatalf("collectSchemas: %v", err)
+	}
+	if len(selected) != 6 {
+		t.Errorf("expected all 6 subjects selected, got %v", selected)
+	}
+
+	orders := info.Topics[0].Schemas
+	if orders == nil || orders.Key == nil || orders.Value == nil {
+		t.Fatalf("expected key and value subjects for orders, got %+v", orders)
+	}
+	if orders.Key.Type != "AVRO" || orders.Key.Compatibility != "BACKWARD" {
+		t.Errorf("orders-key: expected AVRO with the global compatibility (404 fallback), got %+v", orders.Key)
+	}
+	if orders.Value.Type != "PROTOBUF" || orders.Value.Compatibility != "FULL" || orders.Value.Version != 2 || orders.Value.ID != 3 {
+		t.Errorf("orders-value: unexpected %+v", orders.Value)
+	}
+
+	payments := info.Topics[1].Schemas
+	if payments == nil || len(payments.Records) != 2 {
+		t.Fatalf("expected two record subjects for payments, got %+v", payments)
+	}
+	strategies := map[string]string{}
+	for _, record := range payments.Records {
+		strategies[record.Subject] = record.Strategy
+	}
+	if strategies["payments-com.acme.Refund"] != StrategyTopicRecordName || strategies["com.acme.Payment"] != StrategyRecordName {
+		t.Errorf("unexpected strategies: %v", strategies)
+	}
+	if sample := info.Topics[1].Sample; sample.ValueFormat != PayloadAvro {
+		t.Errorf("expected the sampled format to resolve to avro, got %s", sample.ValueFormat)
+	}
+
+	sr := info.SchemaRegistry
+	if sr.Compatibility != "BACKWARD" || sr.Subjects != 6 {
+		t.Errorf("unexpected summary: %+v", sr)
+	}
+	if !reflect.DeepEqual(sr.TopicsWithoutSchema, []string{"audit"}) {
+		t.Errorf("topics without schema: %v", sr.TopicsWithoutSchema)
+	}
+	if !reflect.DeepEqual(sr.OrphanedSubjects, []string{"legacy-value"}) {
+		t.Errorf("orphaned subjects: %v", sr.OrphanedSubjects)
+	}
+	if !reflect.DeepEqual(sr.UnmatchedSubjects, []string{"com.acme.Common"}) {
+		t.Errorf("unmatched subjects: %v", sr.UnmatchedSubjects)
+	}
+}
+
+func TestExportSchemas(t *testing.T) {
+	server := fakeSchemaRegistry(t, schemaRegistryRoutes)
+
+	// Credentials in the URL
+	client, err := newSchemaRegistryClient(SchemaRegistryOptions{URL: "http://sr-user:sr-secret@" + server.Listener.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "schemas.json")
+	if err := exportSchemas(client, []string{"orders-value"}, "BACKWARD", filename); err != nil {
+		t.Fatalf("exportSchemas: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export SchemaRegistryExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.URL != "http://"+server.Listener.Addr().String() {
+		t.Errorf("credentials leaked into the exported URL: %s", export.URL)
+	}
+	if len(export.Subjects) != 2 {
+		t.Fatalf("expected two subjects, got %+v", export.Subjects)
+	}
+
+	// Referenced subjects come first
+	common, orders := export.Subjects[0], export.Subjects[1]
+	if common.Subject != "com.acme.Common" || orders.Subject != "orders-value" {
+		t.Errorf("unexpected order: %s, %s", common.Subject, orders.Subject)
+	}
+	if common.Compatibility != "" || orders.Compatibility != "FULL" {
+		t.Errorf("unexpected compatibility overrides: %q, %q", common.Compatibility, orders.Compatibility)
+	}
+	if len(orders.Versions) != 2 || len(orders.Versions[1].References) != 1 {
+		t.Errorf("unexpected orders-value versions: %+v", orders.Versions)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Script dialects: the CLI used by generated recreate and restore scripts
+const (
+	DialectKafka     = "kafka"     // kafka-topics.sh / kafka-consumer-groups.sh
+	DialectRpk       = "rpk"       // Redpanda rpk
+	DialectConfluent = "confluent" // Confluent CLI; offsets are restored with kafka-consumer-groups.sh
+)
+
+// parseScriptDialect validates the -script-dialect flag
+func parseScriptDialect(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", DialectKafka, "apache":
+		return DialectKafka, nil
+	case DialectRpk, "redpanda":
+		return DialectRpk, nil
+	case DialectConfluent, "confluent-cloud":
+		return DialectConfluent, nil
+	}
+	return "", fmt.Errorf("unknown script dialect %q (use kafka, rpk or confluent)", value)
+}
+
+// shellQuote quotes s for bash as a single word, without expansion
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// scriptCommentName quotes a name for a script comment. Line breaks are escaped, so a name
+// cannot end the comment and start a command.
+func scriptCommentName(s string) string {
+	return shellQuote(strings.NewReplacer("\n", `\n`, "\r", `\r`).Replace(s))
+}
+
+// sortedConfigPairs returns a topic's configs as sorted key=value pairs
+func sortedConfigPairs(configs map[string]string) []string {
+	pairs := make([]string, 0, len(configs))
+	for k, v := range configs {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// recreateToolLines are the target and tool settings of a recreate script
+func recreateToolLines(dialect, commandConfig string) string {
+	switch dialect {
+	case DialectRpk:
+		return "# Target cluster configuration\n" +
+			"BOOTSTRAP_SERVERS=\"localhost:9092\"  # CHANGE THIS\n" +
+			"# Authentication: use an rpk profile (rpk profile create) or -X flags, e.g.\n" +
+			"# RPK_FLAGS=\"-X tls.enabled=true -X sasl.mechanism=SCRAM-SHA-256 -X user=admin -X pass=secret\"\n" +
+			"RPK_FLAGS=\"\"\n\n" +
+			"# rpk command (adjust path if needed)\n" +
+			"RPK=\"rpk\"\n\n"
+	case DialectConfluent:
+		return "# Target cluster: log in first (confluent login), then set the cluster and environment\n" +
+			"CLUSTER_ID=\"lkc-xxxxxx\"  # CHANGE THIS\n" +
+			"ENVIRONMENT_ID=\"\"        # optional, default: current environment\n" +
+			"CONFLUENT_FLAGS=\"--cluster $CLUSTER_ID${ENVIRONMENT_ID:+ --environment $ENVIRONMENT_ID}\"\n\n" +
+			"# Confluent CLI command (adjust path if needed)\n" +
+			"CONFLUENT=\"confluent\"\n\n" +
+			"# Topic configs are passed as files, so values with commas need no escaping\n" +
+			"CONFIG_DIR=$(mktemp -d)\n" +
+			"trap 'rm -rf \"$CONFIG_DIR\"' EXIT\n\n"
+	}
+	return "# Target cluster configuration\n" +
+		"BOOTSTRAP_SERVERS=\"localhost:9092\"  # CHANGE THIS\n" +
+		commandConfigLines(commandConfig) + "\n" +
+		"# Kafka topics command (adjust path if needed)\n" +
+		"KAFKA_TOPICS=\"kafka-topics.sh\"\n\n"
+}
+
+// createTopicCommand returns the commands creating one topic, counting the result in
+// CREATED, SKIPPED or FAILED
+func createTopicCommand(dialect, name string, topic TopicInfo, index int) string {
+	configs := sortedConfigPairs(topic.Configs)
+
+	switch dialect {
+	case DialectRpk:
+		cmd := fmt.Sprintf("if OUTPUT=$($RPK topic create %s -X brokers=\"$BOOTSTRAP_SERVERS\" $RPK_FLAGS \\\n", shellQuote(name))
+		cmd += fmt.Sprintf("  --partitions %d \\\n", topic.Partitions)
+		cmd += fmt.Sprintf("  --replicas %d", topic.ReplicationFactor)
+		for _, config := range configs {
+			cmd += fmt.Sprintf(" \\\n  --topic-config %s", shellQuote(config))
+		}
+		cmd += " 2>&1); then\n"
+		return cmd + createResultLines(name)
+
+	case DialectConfluent:
+		var cmd string
+		configFile := fmt.Sprintf("\"$CONFIG_DIR/topic-%d.properties\"", index)
+		if len(configs) > 0 {
+			cmd += fmt.Sprintf("cat > %s << 'CONFIG_EOF'\n", configFile)
+			cmd += strings.Join(configs, "\n") + "\n"
+			cmd += "CONFIG_EOF\n"
+		}
+		// Confluent Cloud sets the replication factor itself
+		cmd += fmt.Sprintf("# Source replication factor: %d\n", topic.ReplicationFactor)
+		cmd += fmt.Sprintf("if OUTPUT=$($CONFLUENT kafka topic create %s $CONFLUENT_FLAGS \\\n", shellQuote(name))
+		cmd += fmt.Sprintf("  --partitions %d", topic.Partitions)
+		if len(configs) > 0 {
+			cmd += fmt.Sprintf(" \\\n  --config %s", configFile)
+		}
+		cmd += " 2>&1); then\n"
+		return cmd + createResultLines(name)
+	}
+
+	// Build the kafka-topics command
+	cmd := fmt.Sprintf("if $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG \\\n")
+	cmd += fmt.Sprintf("  --create \\\n")
+	cmd += fmt.Sprintf("  --topic %s \\\n", shellQuote(name))
+	cmd += fmt.Sprintf("  --partitions %d \\\n", topic.Partitions)
+	cmd += fmt.Sprintf("  --replication-factor %d", topic.ReplicationFactor)
+	for _, config := range configs {
+		cmd += fmt.Sprintf(" \\\n  --config %s", shellQuote(config))
+	}
+
+	cmd += "; then\n"
+	cmd += "  echo \"  ✓ Created successfully\"\n"
+	cmd += "  CREATED=$((CREATED + 1))\n"
+	cmd += "else\n"
+	cmd += "  echo \"  ✗ Failed to create (may already exist)\"\n"
+	cmd += fmt.Sprintf("  FAILED_TOPICS+=(%s)\n", shellQuote("  - "+name))
+	cmd += "  FAILED=$((FAILED + 1))\n"
+	cmd += "fi\n"
+	return cmd
+}
+
+// createResultLines evaluates a create command whose output was captured in OUTPUT. rpk and
+// the Confluent CLI exit non-zero for existing topics, which are counted as skipped.
+func createResultLines(name string) string {
+	lines := "  echo \"  ✓ Created successfully\"\n"
+	lines += "  CREATED=$((CREATED + 1))\n"
+	lines += "elif echo \"$OUTPUT\" | grep -qi \"already exists\"; then\n"
+	lines += "  echo \"  - Already exists, skipped\"\n"
+	lines += "  SKIPPED=$((SKIPPED + 1))\n"
+	lines += "else\n"
+	lines += "  echo \"$OUTPUT\" | sed 's/^/    /'\n"
+	lines += "  echo \"  ✗ Failed to create\"\n"
+	lines += fmt.Sprintf("  FAILED_TOPICS+=(%s)\n", shellQuote("  - "+name))
+	lines += "  FAILED=$((FAILED + 1))\n"
+	lines += "fi\n"
+	return lines
+}
+
+// recreateVerifyLines are the closing notes of a recreate script
+func recreateVerifyLines(dialect string) string {
+	switch dialect {
+	case DialectRpk:
+		return "# Note: To verify topics were created correctly:\n" +
+			"# $RPK topic list -X brokers=\"$BOOTSTRAP_SERVERS\" $RPK_FLAGS\n" +
+			"# $RPK topic describe <topic-name> -X brokers=\"$BOOTSTRAP_SERVERS\" $RPK_FLAGS\n"
+	case DialectConfluent:
+		return "# Note: To verify topics were created correctly:\n" +
+			"# $CONFLUENT kafka topic list $CONFLUENT_FLAGS\n" +
+			"# $CONFLUENT kafka topic describe <topic-name> $CONFLUENT_FLAGS\n"
+	}
+	return "# Note: To verify topics were created correctly:\n" +
+		"# $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --list\n" +
+		"# $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --describe --topic <topic-name>\n"
+}
+
+// rpkSeekCommand returns the commands restoring a group's offsets on one topic with rpk group seek,
+// which reads "topic partition offset" lines from a file
+func rpkSeekCommand(group, topic string, partitions []PartitionOffset, file string) string {
+	cmd := fmt.Sprintf("cat > %s << 'OFFSET_EOF'\n", file)
+	for _, p := range partitions {
+		cmd += fmt.Sprintf("%s %d %d\n", topic, p.Partition, p.Offset)
+	}
+	cmd += "OFFSET_EOF\n\n"
+
+	cmd += fmt.Sprintf("if OUTPUT=$($RPK group seek %s -X brokers=\"$BOOTSTRAP_SERVERS\" $RPK_FLAGS \\\n", shellQuote(group))
+	cmd += fmt.Sprintf("  --to-file %s 2>&1); then\n", file)
+	cmd += fmt.Sprintf("  echo \"    ✓ Restored %d partitions\"\n", len(partitions))
+	cmd += "  RESTORED=$((RESTORED + 1))\n"
+	cmd += "else\n"
+	cmd += "  echo \"$OUTPUT\" | sed 's/^/      /'\n"
+	cmd += "  echo \"    ✗ Failed to restore offsets (the group must have no active members)\"\n"
+	cmd += "  FAILED=$((FAILED + 1))\n"
+	cmd += "fi\n"
+	return cmd
+}
+
+// restoreAuthHint names where a restore script's authentication settings go
+func restoreAuthHint(dialect string) string {
+	if dialect == DialectRpk {
+		return "RPK_FLAGS or an rpk profile"
+	}
+	return "--command-config, etc."
+}
+
+// restoreToolLines are the target and tool settings of an offset restore script. The Confluent
+// CLI cannot set committed offsets, so that dialect uses kafka-consumer-groups.sh with an API key.
+func restoreToolLines(dialect, commandConfig string) string {
+	switch dialect {
+	case DialectRpk:
+		return "# Target cluster configuration\n" +
+			"BOOTSTRAP_SERVERS=\"localhost:9092\"  # CHANGE THIS\n" +
+			"# Authentication: use an rpk profile (rpk profile create) or -X flags\n" +
+			"RPK_FLAGS=\"\"\n\n" +
+			"# rpk command (adjust path if needed)\n" +
+			"RPK=\"rpk\"\n\n" +
+			offsetsDirLines
+	case DialectConfluent:
+		return "# Target cluster configuration: the Confluent Cloud bootstrap endpoint, with an API key\n" +
+			"# in the --command-config file (the Confluent CLI cannot set committed offsets)\n" +
+			"BOOTSTRAP_SERVERS=\"pkc-xxxxx.region.provider.confluent.cloud:9092\"  # CHANGE THIS\n" +
+			commandConfigLines(commandConfig) + "\n" +
+			"# Kafka consumer groups command (adjust path if needed)\n" +
+			"KAFKA_CONSUMER_GROUPS=\"kafka-consumer-groups.sh\"\n\n" +
+			offsetsDirLines
+	}
+	return "# Target cluster configuration\n" +
+		"BOOTSTRAP_SERVERS=\"localhost:9092\"  # CHANGE THIS\n" +
+		commandConfigLines(commandConfig) + "\n" +
+		"# Kafka consumer groups command (adjust path if needed)\n" +
+		"KAFKA_CONSUMER_GROUPS=\"kafka-consumer-groups.sh\"\n\n" +
+		offsetsDirLines
+}
+
+// offsetsDirLines create the directory for the offset files of a restore script
+const offsetsDirLines = "OFFSETS_DIR=$(mktemp -d)\n" +
+	"trap 'rm -rf \"$OFFSETS_DIR\"' EXIT\n"
+
+// restoreVerifyLine is the closing hint of an offset restore script
+func restoreVerifyLine(dialect string) string {
+	if dialect == DialectRpk {
+		return "echo \"  $RPK group describe <group-name> -X brokers=$BOOTSTRAP_SERVERS $RPK_FLAGS\"\n"
+	}
+	return "echo \"  $KAFKA_CONSUMER_GROUPS --bootstrap-server \\\"$BOOTSTRAP_SERVERS\\\" $COMMAND_CONFIG --group <group-name> --describe\"\n"
+}
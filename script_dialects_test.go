@@ -0,0 +1,226 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateTopicCommand(t *testing.T) {
+	topic := TopicInfo{
+		Name:              "orders",
+		Partitions:        6,
+		ReplicationFactor: 3,
+		Configs:           map[string]string{"retention.ms": "604800000", "cleanup.policy": "compact,delete"},
+	}
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{DialectKafka, `if $KAFKA_TOPICS --bootstrap-server "$BOOTSTRAP_SERVERS" $COMMAND_CONFIG \
+  --create \
+  --topic 'orders' \
+  --partitions 6 \
+  --replication-factor 3 \
+  --config 'cleanup.policy=compact,delete' \
+  --config 'retention.ms=604800000'; then
+  echo "  ✓ Created successfully"
+  CREATED=$((CREATED + 1))
+else
+  echo "  ✗ Failed to create (may already exist)"
+  FAILED_TOPICS+=('  - orders')
+  FAILED=$((FAILED + 1))
+fi
+`},
+		{DialectRpk, `if OUTPUT=$($RPK topic create 'orders' -X brokers="$BOOTSTRAP_SERVERS" $RPK_FLAGS \
+  --partitions 6 \
+  --replicas 3 \
+  --topic-config 'cleanup.policy=compact,delete' \
+  --topic-config 'retention.ms=604800000' 2>&1); then
+  echo "  ✓ Created successfully"
+  CREATED=$((CREATED + 1))
+elif echo "$OUTPUT" | grep -qi "already exists"; then
+  echo "  - Already exists, skipped"
+  SKIPPED=$((SKIPPED + 1))
+else
+  echo "$OUTPUT" | sed 's/^/    /'
+  echo "  ✗ Failed to create"
+  FAILED_TOPICS+=('  - orders')
+  FAILED=$((FAILED + 1))
+fi
+`},
+		{DialectConfluent, `cat > "$CONFIG_DIR/topic-2.properties" << 'CONFIG_EOF'
+cleanup.policy=compact,delete
+retention.ms=604800000
+CONFIG_EOF
+# Source replication factor: 3
+if OUTPUT=$($CONFLUENT kafka topic create 'orders' $CONFLUENT_FLAGS \
+  --partitions 6 \
+  --config "$CONFIG_DIR/topic-2.properties" 2>&1); then
+  echo "  ✓ Created successfully"
+  CREATED=$((CREATED + 1))
+elif echo "$OUTPUT" | grep -qi "already exists"; then
+  echo "  - Already exists, skipped"
+  SKIPPED=$((SKIPPED + 1))
+else
+  echo "$OUTPUT" | sed 's/^/    /'
+  echo "  ✗ Failed to create"
+  FAILED_TOPICS+=('  - orders')
+  FAILED=$((FAILED + 1))
+fi
+`},
+	}
+	for _, tt := range tests {
+		if got := createTopicCommand(tt.dialect, "orders", topic, 2); got != tt.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.dialect, got, tt.want)
+		}
+	}
+}
+
+func TestRestoreOffsetsCommands(t *testing.T) {
+	backup := &ConsumerOffsetsBackup{ConsumerGroups: []ConsumerGroupOffsets{{
+		Group:  "billing",
+		Topics: map[string][]PartitionOffset{"orders": {{Partition: 0, Offset: 42}, {Partition: 1, Offset: 43}}},
+	}}}
+	kafkaRestore := `cat > "$OFFSETS_DIR/offsets-1-1.json" << 'OFFSET_EOF'
+{
+  "partitions": [
+    {"topic": "orders", "partition": 0, "offset": 42},
+    {"topic": "orders", "partition": 1, "offset": 43}
+  ]
+}
+OFFSET_EOF
+
+if $KAFKA_CONSUMER_GROUPS --bootstrap-server "$BOOTSTRAP_SERVERS" $COMMAND_CONFIG \
+  --group 'billing' \
+  --topic 'orders' \
+  --reset-offsets \
+  --from-json-file "$OFFSETS_DIR/offsets-1-1.json" \
+  --execute; then
+`
+	tests := []struct {
+		dialect string
+		want    []string
+	}{
+		{DialectKafka, []string{"KAFKA_CONSUMER_GROUPS=\"kafka-consumer-groups.sh\"\n", "OFFSETS_DIR=$(mktemp -d)\n", kafkaRestore}},
+		{DialectConfluent, []string{"BOOTSTRAP_SERVERS=\"pkc-", "OFFSETS_DIR=$(mktemp -d)\n", kafkaRestore}},
+		{DialectRpk, []string{"RPK=\"rpk\"\n", "OFFSETS_DIR=$(mktemp -d)\n", `cat > "$OFFSETS_DIR/offsets-1-1.txt" << 'OFFSET_EOF'
+orders 0 42
+orders 1 43
+OFFSET_EOF
+
+if OUTPUT=$($RPK group seek 'billing' -X brokers="$BOOTSTRAP_SERVERS" $RPK_FLAGS \
+  --to-file "$OFFSETS_DIR/offsets-1-1.txt" 2>&1); then
+`}},
+	}
+	for _, tt := range tests {
+		filename := filepath.Join(t.TempDir(), "restore.sh")
+		if err := generateRestoreOffsetsScript(backup, filename, RestoreOptions{Dialect: tt.dialect}); err != nil {
+			t.Fatalf("%s: generateRestoreOffsetsScript: %v", tt.dialect, err)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s: script does not contain\n%s\n\nscript:\n%s", tt.dialect, want, data)
+			}
+		}
+	}
+}
+
+func TestVerifyLines(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		recreate string
+		restore  string
+	}{
+		{
+			DialectKafka,
+			"# $KAFKA_TOPICS --bootstrap-server \"$BOOTSTRAP_SERVERS\" $COMMAND_CONFIG --describe --topic <topic-name>\n",
+			"echo \"  $KAFKA_CONSUMER_GROUPS --bootstrap-server \\\"$BOOTSTRAP_SERVERS\\\" $COMMAND_CONFIG --group <group-name> --describe\"\n",
+		},
+		{
+			DialectRpk,
+			"# $RPK topic describe <topic-name> -X brokers=\"$BOOTSTRAP_SERVERS\" $RPK_FLAGS\n",
+			"echo \"  $RPK group describe <group-name> -X brokers=$BOOTSTRAP_SERVERS $RPK_FLAGS\"\n",
+		},
+		{
+			DialectConfluent,
+			"# $CONFLUENT kafka topic describe <topic-name> $CONFLUENT_FLAGS\n",
+			"echo \"  $KAFKA_CONSUMER_GROUPS --bootstrap-server \\\"$BOOTSTRAP_SERVERS\\\" $COMMAND_CONFIG --group <group-name> --describe\"\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := recreateVerifyLines(tt.dialect); !strings.HasSuffix(got, tt.recreate) {
+			t.Errorf("%s recreate:\ngot:\n%s\nwant suffix:\n%s", tt.dialect, got, tt.recreate)
+		}
+		if got := restoreVerifyLine(tt.dialect); got != tt.restore {
+			t.Errorf("%s restore:\ngot:  %s\nwant: %s", tt.dialect, got, tt.restore)
+		}
+	}
+}
+
+func TestRecreateScriptQuotesConfigs(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	for _, dialect := range []string{DialectKafka, DialectRpk, DialectConfluent} {
+		dir := t.TempDir()
+		marker := filepath.Join(dir, "pwned")
+		value := `it's "quoted" $HOME $(touch ` + marker + `) ` + "`touch " + marker + "`"
+		info := &KafkaClusterInfo{Topics: []TopicInfo{{
+			Name:              "orders",
+			Partitions:        3,
+			ReplicationFactor: 1,
+			Configs:           map[string]string{"x.custom": value},
+		}}}
+		script := filepath.Join(dir, "recreate.sh")
+		if err := generateRecreateScript(info, script, RecreateOptions{Dialect: dialect}); err != nil {
+			t.Fatalf("%s: generateRecreateScript: %v", dialect, err)
+		}
+
+		// Stand-ins for the CLIs record their arguments and config files, NUL-separated
+		args := filepath.Join(dir, "args")
+		fake := "#!/bin/bash\n" +
+			"for a in \"$@\"; do printf '%s\\0' \"$a\" >> " + shellQuote(args) + "; done\n" +
+			"while [ $# -gt 0 ]; do\n" +
+			"  if [ \"$1\" = --config ] && [ -f \"$2\" ]; then cat \"$2\" | tr '\\n' '\\0' >> " + shellQuote(args) + "; fi\n" +
+			"  shift\n" +
+			"done\n"
+		for _, tool := range []string{"kafka-topics.sh", "rpk", "confluent"} {
+			if err := os.WriteFile(filepath.Join(dir, tool), []byte(fake), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cmd := exec.Command(bash, script)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v\n%s", dialect, err, out)
+		}
+		if _, err := os.Stat(marker); err == nil {
+			t.Fatalf("%s: a config value was executed", dialect)
+		}
+
+		data, err := os.ReadFile(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, arg := range strings.Split(string(data), "\x00") {
+			if arg == "x.custom="+value {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: config was not passed verbatim: %q", dialect, data)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// SecurityOptions holds the authentication and TLS settings given on the command line
+type SecurityOptions struct {
+	Protocol      string
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+
+	// Kerberos (SASL/GSSAPI)
+	KerberosServiceName string
+	KerberosRealm       string
+	KerberosConfigPath  string
+	KerberosKeytab      string
+	KerberosCCache      string
+	KerberosDisableFAST bool
+
+	// AWS MSK IAM
+	AWSRegion  string
+	AWSProfile string
+	Brokers    []string // used to infer the AWS region from MSK hostnames
+
+	// TLS/SSL
+	TLSCACert     string
+	TLSClientCert string
+	TLSClientKey  string
+	TLSSkipVerify bool
+}
+
+// configureSecurity applies the security protocol, SASL and TLS settings to the sarama config
+func configureSecurity(config *sarama.Config, opts SecurityOptions) error {
+	// Configure security protocol
+	if opts.Protocol != "" {
+		switch strings.ToUpper(opts.Protocol) {
+		case "SASL_SSL":
+			config.Net.SASL.Enable = true
+			config.Net.TLS.Enable = true
+		case "SASL_PLAINTEXT":
+			config.Net.SASL.Enable = true
+			config.Net.TLS.Enable = false
+		case "SSL":
+			config.Net.TLS.Enable = true
+		default:
+			return fmt.Errorf("unknown security protocol: %s", opts.Protocol)
+		}
+	}
+
+	// Configure SASL
+	if config.Net.SASL.Enable {
+		// AWS MSK requires SASL handshake version 1
+		config.Net.SASL.Handshake = true
+		config.Net.SASL.Version = 1
+
+		mechanism := strings.ToUpper(opts.SASLMechanism)
+		if mechanism == "GSSAPI" {
+			if err := configureKerberos(config, opts); err != nil {
+				return err
+			}
+		} else if mechanism == "AWS_MSK_IAM" {
+			if err := configureMSKIAM(config, opts); err != nil {
+				return err
+			}
+		} else if mechanism == "OAUTHBEARER" {
+			return fmt.Errorf("generic SASL/OAUTHBEARER is not supported; use -sasl-mechanism AWS_MSK_IAM for AWS MSK IAM authentication")
+		} else {
+			if opts.SASLUsername == "" || opts.SASLPassword == "" {
+				return fmt.Errorf("SASL username and password are required when using SASL authentication")
+			}
+
+			config.Net.SASL.User = opts.SASLUsername
+			config.Net.SASL.Password = opts.SASLPassword
+
+			switch mechanism {
+			case "PLAIN":
+				config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+			case "SCRAM-SHA-256":
+				config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+				config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA256} }
+			case "SCRAM-SHA-512":
+				config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+				config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA512} }
+			default:
+				return fmt.Errorf("unknown SASL mechanism: %s", opts.SASLMechanism)
+			}
+		}
+
+		log.Printf("Using SASL authentication: protocol=%s, mechanism=%s, user=%s", opts.Protocol, config.Net.SASL.Mechanism, saslPrincipal(config))
+	}
+
+	// Configure TLS
+	if config.Net.TLS.Enable {
+		if (opts.TLSClientCert == "") != (opts.TLSClientKey == "") {
+			return fmt.Errorf("both -tls-client-cert and -tls-client-key must be provided for mTLS")
+		}
+		tlsConfig, err := loadTLSConfig(opts.TLSCACert, opts.TLSClientCert, opts.TLSClientKey, opts.TLSSkipVerify)
+		if err != nil {
+			return err
+		}
+
+		config.Net.TLS.Config = tlsConfig
+
+		if opts.TLSSkipVerify {
+			log.Printf("WARNING: TLS certificate verification is disabled (insecure)")
+		}
+
+		// Only log TLS auth if not using SASL (where TLS is just the transport layer)
+		if !config.Net.SASL.Enable {
+			authType := "TLS/SSL"
+			if len(tlsConfig.Certificates) > 0 {
+				authType = "mTLS (mutual TLS)"
+			}
+			log.Printf("Using %s authentication", authType)
+		}
+	}
+
+	return nil
+}
+
+// loadTLSConfig builds a TLS config from an optional CA certificate (system pool otherwise)
+// and an optional client certificate and key for mTLS
+func loadTLSConfig(caCertPath, clientCertPath, clientKeyPath string, skipVerify bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: skipVerify,
+	}
+
+	// Load CA certificate if provided
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate: %v", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+		log.Printf("Loaded CA certificate from: %s", caCertPath)
+	} else if !skipVerify {
+		// Use system certificates when no CA cert provided and verification enabled
+		// This is needed for AWS MSK and other managed Kafka services
+		systemCertPool, err := x509.SystemCertPool()
+		if err != nil {
+			log.Printf("Warning: Failed to load system certificates, using empty pool: %v", err)
+			systemCertPool = x509.NewCertPool()
+		}
+		tlsConfig.RootCAs = systemCertPool
+	}
+
+	// Load client certificate and key for mTLS
+	if clientCertPath != "" && clientKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate/key: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		log.Printf("Loaded client certificate for mTLS from: %s", clientCertPath)
+	}
+
+	return tlsConfig, nil
+}
+
+// saslPrincipal returns the identity used for SASL authentication, for logging
+func saslPrincipal(config *sarama.Config) string {
+	switch config.Net.SASL.Mechanism {
+	case sarama.SASLTypeGSSAPI:
+		return config.Net.SASL.GSSAPI.Username + "@" + config.Net.SASL.GSSAPI.Realm
+	case sarama.SASLTypeOAuth:
+		return "AWS IAM"
+	}
+	return config.Net.SASL.User
+}
+
+// authErrorHint returns an explanation for connection errors caused by the configured authentication
+func authErrorHint(config *sarama.Config, err error) string {
+	if err == nil || !config.Net.SASL.Enable {
+		return ""
+	}
+
+	switch config.Net.SASL.Mechanism {
+	case sarama.SASLTypeGSSAPI:
+		return explainKerberosError(err)
+	case sarama.SASLTypeOAuth:
+		return "\nHint: check that the IAM policy allows kafka-cluster:Connect on the cluster, that the brokers use the IAM port (9098) and that -aws-region matches the cluster"
+	}
+	return ""
+}
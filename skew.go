@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// minSkewMessages is the smallest topic checked for skew; a handful of messages is always uneven
+const minSkewMessages = 1000
+
+// PartitionSkew describes how unevenly a topic's data is spread over its partitions
+type PartitionSkew struct {
+	Messages SkewStats  `json:"messages"`
+	Size     *SkewStats `json:"size,omitempty"` // from -topic-sizes-input
+	Skewed   bool       `json:"skewed"`
+}
+
+// SkewStats are the spread of one per-partition metric
+type SkewStats struct {
+	MaxMeanRatio     float64 `json:"max_mean_ratio"`
+	CV               float64 `json:"coefficient_of_variation"`
+	HottestPartition int32   `json:"hottest_partition"`
+	HottestShare     float64 `json:"hottest_share"` // fraction of the topic in the hottest partition
+}
+
+// skewStats computes max/mean, coefficient of variation and the hottest partition.
+// Returns nil for fewer than two partitions or no data.
+func skewStats(values map[int32]int64) *SkewStats {
+	if len(values) < 2 {
+		return nil
+	}
+
+	partitions := make([]int32, 0, len(values))
+	var total int64
+	for partition, value := range values {
+		partitions = append(partitions, partition)
+		total += value
+	}
+	if total <= 0 {
+		return nil
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	mean := float64(total) / float64(len(values))
+	var variance float64
+	stats := &SkewStats{HottestPartition: partitions[0]}
+	for _, partition := range partitions {
+		value := values[partition]
+		variance += (float64(value) - mean) * (float64(value) - mean)
+		if value > values[stats.HottestPartition] {
+			stats.HottestPartition = partition
+		}
+	}
+	variance /= float64(len(values))
+
+	max := values[stats.HottestPartition]
+	stats.MaxMeanRatio = float64(max) / mean
+	stats.CV = math.Sqrt(variance) / mean
+	stats.HottestShare = float64(max) / float64(total)
+	return stats
+}
+
+// partitionSkew returns the skew of a topic from per-partition message counts and, if known,
+// per-partition sizes. The topic is flagged when either max/mean ratio reaches threshold.
+func partitionSkew(messages, sizes map[int32]int64, threshold float64) *PartitionSkew {
+	var total int64
+	for _, count := range messages {
+		total += count
+	}
+	if total < minSkewMessages {
+		return nil
+	}
+
+	byMessages := skewStats(messages)
+	if byMessages == nil {
+		return nil
+	}
+	skew := &PartitionSkew{
+		Messages: *byMessages,
+		Size:     skewStats(sizes),
+	}
+	skew.Skewed = skew.Messages.MaxMeanRatio >= threshold ||
+		(skew.Size != nil && skew.Size.MaxMeanRatio >= threshold)
+	return skew
+}
+
+// formatPercent formats a fraction as a percentage, e.g. 0.425 as 42.5%
+func formatPercent(fraction float64) string {
+	return fmt.Sprintf("%.1f%%", fraction*100)
+}
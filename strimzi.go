@@ -0,0 +1,315 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StrimziOptions controls the generated Strimzi custom resources
+type StrimziOptions struct {
+	Namespace string
+	Cluster   string // value of the strimzi.io/cluster label
+	UserAuth  string // KafkaUser authentication type: scram-sha-512, tls, tls-external or none
+	Overrides *TopicOverrides
+}
+
+// StrimziResource is the common shape of KafkaTopic and KafkaUser manifests
+type StrimziResource struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Metadata   StrimziMetadata `json:"metadata"`
+	Spec       interface{}     `json:"spec"`
+}
+
+type StrimziMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type KafkaTopicSpec struct {
+	TopicName  string            `json:"topicName,omitempty"`
+	Partitions int               `json:"partitions"`
+	Replicas   int               `json:"replicas"`
+	Config     map[string]string `json:"config,omitempty"`
+}
+
+type KafkaUserSpec struct {
+	Authentication *KafkaUserAuthentication `json:"authentication,omitempty"`
+	Authorization  KafkaUserAuthorization   `json:"authorization"`
+}
+
+type KafkaUserAuthentication struct {
+	Type string `json:"type"`
+}
+
+type KafkaUserAuthorization struct {
+	Type string         `json:"type"`
+	ACLs []KafkaUserACL `json:"acls"`
+}
+
+type KafkaUserACL struct {
+	Resource   KafkaUserACLResource `json:"resource"`
+	Operations []string             `json:"operations"`
+	Host       string               `json:"host,omitempty"`
+	Type       string               `json:"type"`
+}
+
+type KafkaUserACLResource struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	PatternType string `json:"patternType,omitempty"`
+}
+
+// buildStrimziTopics returns a KafkaTopic for each non-internal topic
+func buildStrimziTopics(info *KafkaClusterInfo, opts StrimziOptions) []StrimziResource {
+	resources := make([]StrimziResource, 0, len(info.Topics))
+	for _, topic := range info.Topics {
+		// Skip internal topics (starting with __)
+		if strings.HasPrefix(topic.Name, "__") {
+			continue
+		}
+
+		topic, notes := opts.Overrides.apply(topic)
+		spec := KafkaTopicSpec{
+			Partitions: topic.Partitions,
+			Replicas:   topic.ReplicationFactor,
+			Config:     topic.Configs,
+		}
+
+		name := kubernetesResourceName(topic.Name)
+		if name != topic.Name {
+			spec.TopicName = topic.Name
+		}
+
+		metadata := strimziMetadata(name, opts)
+		if len(notes) > 0 {
+			metadata.Annotations = map[string]string{"kmap/overrides": strings.Join(notes, "; ")}
+		}
+
+		resources = append(resources, StrimziResource{
+			APIVersion: "kafka.strimzi.io/v1beta2",
+			Kind:       "KafkaTopic",
+			Metadata:   metadata,
+			Spec:       spec,
+		})
+	}
+	return resources
+}
+
+// buildStrimziUsers returns a KafkaUser with simple authorization for each User: principal in the ACLs
+func buildStrimziUsers(info *KafkaClusterInfo, opts StrimziOptions) ([]StrimziResource, []string) {
+	type aclKey struct {
+		resource KafkaUserACLResource
+		host     string
+		perm     string
+	}
+
+	byPrincipal := make(map[string]map[aclKey][]string)
+	var skipped []string
+
+	for _, acl := range info.ACLs {
+		if !strings.HasPrefix(acl.Principal, "User:") {
+			skipped = append(skipped, acl.Principal)
+			continue
+		}
+
+		resource := KafkaUserACLResource{Type: strimziResourceType(acl.ResourceType)}
+		if resource.Type == "" {
+			skipped = append(skipped, fmt.Sprintf("%s (%s resource)", acl.Principal, acl.ResourceType))
+			continue
+		}
+		if resource.Type != "cluster" {
+			resource.Name = acl.ResourceName
+			resource.PatternType = strimziPatternType(acl.PatternType)
+			if resource.PatternType == "" {
+				skipped = append(skipped, fmt.Sprintf("%s (%s pattern)", acl.Principal, acl.PatternType))
+				continue
+			}
+		}
+
+		key := aclKey{resource: resource, host: acl.Host, perm: strings.ToLower(acl.PermissionType)}
+		if byPrincipal[acl.Principal] == nil {
+			byPrincipal[acl.Principal] = make(map[aclKey][]string)
+		}
+		byPrincipal[acl.Principal][key] = append(byPrincipal[acl.Principal][key], acl.Operation)
+	}
+
+	principals := make([]string, 0, len(byPrincipal))
+	for p := range byPrincipal {
+		principals = append(principals, p)
+	}
+	sort.Strings(principals)
+
+	resources := make([]StrimziResource, 0, len(principals))
+	names := make(map[string]bool)
+	for _, principal := range principals {
+		spec := KafkaUserSpec{
+			Authorization: KafkaUserAuthorization{Type: "simple"},
+		}
+		if opts.UserAuth != "" && opts.UserAuth != "none" {
+			spec.Authentication = &KafkaUserAuthentication{Type: opts.UserAuth}
+		}
+
+		for key, operations := range byPrincipal[principal] {
+			sort.Strings(operations)
+			spec.Authorization.ACLs = append(spec.Authorization.ACLs, KafkaUserACL{
+				Resource:   key.resource,
+				Operations: operations,
+				Host:       key.host,
+				Type:       key.perm,
+			})
+		}
+		sort.Slice(spec.Authorization.ACLs, func(i, j int) bool {
+			a, b := spec.Authorization.ACLs[i], spec.Authorization.ACLs[j]
+			if a.Resource.Type != b.Resource.Type {
+				return a.Resource.Type < b.Resource.Type
+			}
+			if a.Resource.Name != b.Resource.Name {
+				return a.Resource.Name < b.Resource.Name
+			}
+			return a.Type < b.Type
+		})
+
+		// TLS principals look like User:CN=alice; Strimzi derives that from the KafkaUser name
+		userName := strings.TrimPrefix(strings.TrimPrefix(principal, "User:"), "CN=")
+		name := kubernetesResourceName(userName)
+		if names[name] {
+			// User:alice and User:CN=alice: the later one is named after the whole principal
+			name = kubernetesResourceName(principal)
+		}
+		names[name] = true
+		metadata := strimziMetadata(name, opts)
+		metadata.Annotations = map[string]string{"kmap/source-principal": principal}
+
+		resources = append(resources, StrimziResource{
+			APIVersion: "kafka.strimzi.io/v1beta2",
+			Kind:       "KafkaUser",
+			Metadata:   metadata,
+			Spec:       spec,
+		})
+	}
+
+	return resources, skipped
+}
+
+func strimziMetadata(name string, opts StrimziOptions) StrimziMetadata {
+	return StrimziMetadata{
+		Name:      name,
+		Namespace: opts.Namespace,
+		Labels:    map[string]string{"strimzi.io/cluster": opts.Cluster},
+	}
+}
+
+// strimziResourceType maps Kafka ACL resource types to KafkaUser resource types
+func strimziResourceType(resourceType string) string {
+	switch resourceType {
+	case "Topic":
+		return "topic"
+	case "Group":
+		return "group"
+	case "Cluster":
+		return "cluster"
+	case "TransactionalID":
+		return "transactionalId"
+	}
+	return ""
+}
+
+// strimziPatternType maps Kafka ACL pattern types to KafkaUser pattern types. Match and Any
+// only exist in ACL filters and have no KafkaUser equivalent.
+func strimziPatternType(patternType string) string {
+	switch patternType {
+	case "Literal":
+		return "literal"
+	case "Prefixed":
+		return "prefix"
+	}
+	return ""
+}
+
+var kubernetesInvalidChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// kubernetesResourceName converts a Kafka name into a valid Kubernetes resource name (DNS-1123 subdomain).
+// Names that need changes get the SHA-1 of the original appended, like the Strimzi Topic Operator does,
+// so "a_b" and "a-b" cannot collide.
+func kubernetesResourceName(name string) string {
+	// Every dot-separated label must start and end with a letter or digit
+	var labels []string
+	for _, label := range strings.Split(kubernetesInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), ".") {
+		if label = strings.Trim(label, "-"); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	sanitized := strings.Join(labels, ".")
+	if sanitized == name && len(name) <= 253 {
+		return name
+	}
+
+	sum := sha1.Sum([]byte(name))
+	if sanitized == "" {
+		return hex.EncodeToString(sum[:])
+	}
+	suffix := "---" + hex.EncodeToString(sum[:])
+	if len(sanitized) > 253-len(suffix) {
+		sanitized = strings.TrimRight(sanitized[:253-len(suffix)], ".-")
+	}
+	return sanitized + suffix
+}
+
+// marshalStrimziDocuments renders resources as a multi-document YAML stream
+func marshalStrimziDocuments(resources []StrimziResource) ([]byte, error) {
+	var out []byte
+	for _, resource := range resources {
+		data, err := marshalYAML(resource)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, "---\n"...)
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+// generateStrimziManifests writes KafkaTopic and KafkaUser manifests. If output is a directory
+// (existing, or ending in /), one file per resource is written to <output>/<namespace>/,
+// otherwise all resources go into a single multi-document YAML file.
+func generateStrimziManifests(info *KafkaClusterInfo, output string, opts StrimziOptions) (int, []string, error) {
+	resources := buildStrimziTopics(info, opts)
+	users, skipped := buildStrimziUsers(info, opts)
+	resources = append(resources, users...)
+
+	if stat, err := os.Stat(output); (err == nil && stat.IsDir()) || strings.HasSuffix(output, "/") {
+		dir := filepath.Join(output, opts.Namespace)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, nil, err
+		}
+		for _, resource := range resources {
+			data, err := marshalStrimziDocuments([]StrimziResource{resource})
+			if err != nil {
+				return 0, nil, err
+			}
+			filename := filepath.Join(dir, strings.ToLower(resource.Kind)+"-"+resource.Metadata.Name+".yaml")
+			if err := os.WriteFile(filename, data, 0644); err != nil {
+				return 0, nil, err
+			}
+		}
+		return len(resources), skipped, nil
+	}
+
+	data, err := marshalStrimziDocuments(resources)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return 0, nil, err
+	}
+	return len(resources), skipped, nil
+}
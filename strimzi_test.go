@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var dns1123Subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+func TestKubernetesResourceName(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string // expected name before the hash suffix; the name itself if unchanged
+		hashed bool
+	}{
+		{"orders", "orders", false},
+		{"orders.v1", "orders.v1", false},
+		{"Orders", "orders", true},
+		{"orders_v1", "orders-v1", true},
+		{"a..b", "a.b", true},
+		{"a.-b", "a.b", true},
+		{"-orders-", "orders", true},
+		{"___", "", true},
+		{"...", "", true},
+		{strings.Repeat("x", 300), strings.Repeat("x", 253-43), true},
+	}
+	for _, tt := range tests {
+		got := kubernetesResourceName(tt.name)
+		if !dns1123Subdomain.MatchString(got) || len(got) > 253 {
+			t.Errorf("kubernetesResourceName(%q) = %q, not a DNS-1123 subdomain", tt.name, got)
+			continue
+		}
+		switch {
+		case !tt.hashed && got != tt.name:
+			t.Errorf("kubernetesResourceName(%q) = %q, want it unchanged", tt.name, got)
+		case tt.hashed && tt.prefix == "" && len(got) != 40:
+			t.Errorf("kubernetesResourceName(%q) = %q, want only the hash", tt.name, got)
+		case tt.hashed && tt.prefix != "" && got[:len(got)-43] != tt.prefix:
+			t.Errorf("kubernetesResourceName(%q) = %q, want prefix %q", tt.name, got, tt.prefix)
+		}
+	}
+
+	if kubernetesResourceName("a_b") == kubernetesResourceName("a-b") {
+		t.Error("a_b and a-b collide")
+	}
+}
+
+func TestBuildStrimziUsersPatternTypes(t *testing.T) {
+	info := &KafkaClusterInfo{ACLs: []ACLInfo{
+		{Principal: "User:alice", Host: "*", Operation: "Read", PermissionType: "Allow", ResourceType: "Topic", ResourceName: "orders", PatternType: "Literal"},
+		{Principal: "User:alice", Host: "*", Operation: "Read", PermissionType: "Allow", ResourceType: "Group", ResourceName: "billing-", PatternType: "Prefixed"},
+		{Principal: "User:alice", Host: "*", Operation: "Read", PermissionType: "Allow", ResourceType: "Topic", ResourceName: "x", PatternType: "Match"},
+	}}
+
+	users, skipped := buildStrimziUsers(info, StrimziOptions{Namespace: "kafka", Cluster: "prod"})
+	if len(users) != 1 {
+		t.Fatalf("expected one KafkaUser, got %d", len(users))
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "Match") {
+		t.Errorf("expected the Match ACL to be skipped, got %v", skipped)
+	}
+
+	patterns := make(map[string]string)
+	for _, acl := range users[0].Spec.(KafkaUserSpec).Authorization.ACLs {
+		patterns[acl.Resource.Name] = acl.Resource.PatternType
+	}
+	if patterns["orders"] != "literal" || patterns["billing-"] != "prefix" || len(patterns) != 2 {
+		t.Errorf("unexpected pattern types: %v", patterns)
+	}
+}
+
+func TestBuildStrimziUsersNameCollision(t *testing.T) {
+	acl := func(principal string) ACLInfo {
+		return ACLInfo{Principal: principal, Host: "*", Operation: "Read", PermissionType: "Allow", ResourceType: "Topic", ResourceName: "orders", PatternType: "Literal"}
+	}
+	info := &KafkaClusterInfo{ACLs: []ACLInfo{acl("User:alice"), acl("User:CN=alice"), acl("User:bob")}}
+
+	users, _ := buildStrimziUsers(info, StrimziOptions{Namespace: "kafka", Cluster: "prod"})
+	if len(users) != 3 {
+		t.Fatalf("expected three KafkaUsers, got %d", len(users))
+	}
+	names := make(map[string]string)
+	for _, user := range users {
+		principal := user.Metadata.Annotations["kmap/source-principal"]
+		if other, ok := names[user.Metadata.Name]; ok {
+			t.Errorf("%s and %s are both named %s", other, principal, user.Metadata.Name)
+		}
+		names[user.Metadata.Name] = principal
+	}
+	if names["alice"] != "User:CN=alice" || names["bob"] != "User:bob" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
+
+func TestBuildStrimziTopicsOverrides(t *testing.T) {
+	overrides, err := loadTopicOverrides("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides.ReplicationFactor.Max = 2
+	overrides.Configs = map[string]string{"retention.ms": "3600000"}
+
+	info := &KafkaClusterInfo{Topics: []TopicInfo{{
+		Name:              "orders",
+		Partitions:        6,
+		ReplicationFactor: 3,
+		Configs:           map[string]string{"message.format.version": "2.8", "cleanup.policy": "compact"},
+	}}}
+	topics := buildStrimziTopics(info, StrimziOptions{Namespace: "kafka", Cluster: "prod", Overrides: overrides})
+	if len(topics) != 1 {
+		t.Fatalf("expected one KafkaTopic, got %d", len(topics))
+	}
+
+	spec := topics[0].Spec.(KafkaTopicSpec)
+	if spec.Replicas != 2 || spec.Partitions != 6 {
+		t.Errorf("expected 6 partitions with 2 replicas, got %d with %d", spec.Partitions, spec.Replicas)
+	}
+	want := map[string]string{"cleanup.policy": "compact", "retention.ms": "3600000"}
+	if len(spec.Config) != len(want) || spec.Config["cleanup.policy"] != want["cleanup.policy"] || spec.Config["retention.ms"] != want["retention.ms"] {
+		t.Errorf("configs: got %v, want %v", spec.Config, want)
+	}
+	if note := topics[0].Metadata.Annotations["kmap/overrides"]; !strings.Contains(note, "replication factor 3 -> 2") {
+		t.Errorf("expected the changes in an annotation, got %q", note)
+	}
+}
+
+func TestBuildStrimziTopicsWithoutOverrides(t *testing.T) {
+	configs := map[string]string{"message.format.version": "2.8", "cleanup.policy": "compact"}
+	info := &KafkaClusterInfo{Topics: []TopicInfo{{Name: "orders", Partitions: 6, ReplicationFactor: 3, Configs: configs}}}
+	topics := buildStrimziTopics(info, StrimziOptions{Namespace: "kafka", Cluster: "prod"})
+	if len(topics) != 1 {
+		t.Fatalf("expected one KafkaTopic, got %d", len(topics))
+	}
+	if spec := topics[0].Spec.(KafkaTopicSpec); !reflect.DeepEqual(spec.Config, configs) {
+		t.Errorf("configs: got %v, want %v", spec.Config, configs)
+	}
+	if topics[0].Metadata.Annotations != nil {
+		t.Errorf("unexpected annotations %v", topics[0].Metadata.Annotations)
+	}
+}
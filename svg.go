@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Layout metrics for the built-in SVG renderer
+const (
+	svgMargin       = 20.0
+	svgNodeHeight   = 40.0
+	svgNodeMinWidth = 120.0
+	svgNodeGap      = 12.0
+	svgBlockGap     = 24.0
+	svgColumnGap    = 140.0
+	svgClusterPad   = 14.0
+	svgClusterTitle = 22.0
+	svgCharWidth    = 7.0 // approximate width of a 12px sans-serif character
+)
+
+type svgBox struct {
+	x, y, w, h float64
+}
+
+// svgBlock is a cluster, or a single node outside any cluster, placed as one unit
+type svgBlock struct {
+	cluster *diagramCluster
+	nodes   []diagramNode
+	index   int
+	layer   int
+	order   float64
+	box     svgBox
+}
+
+// svgLayout is a left-to-right layered layout of a diagramGraph, similar to dot with rankdir=LR:
+// blocks are assigned to columns by longest path along the edges, and nodes are ordered by the
+// position of their predecessors to reduce edge crossings.
+type svgLayout struct {
+	blocks  []*svgBlock
+	blockOf map[string]*svgBlock
+	nodes   map[string]svgBox
+	width   float64
+	height  float64
+}
+
+func layoutSVG(g *diagramGraph) *svgLayout {
+	l := &svgLayout{blockOf: make(map[string]*svgBlock), nodes: make(map[string]svgBox)}
+
+	addBlock := func(cluster *diagramCluster, nodes []diagramNode) {
+		b := &svgBlock{cluster: cluster, nodes: append([]diagramNode(nil), nodes...), index: len(l.blocks)}
+		l.blocks = append(l.blocks, b)
+		for _, node := range nodes {
+			l.blockOf[node.ID] = b
+		}
+	}
+	for i := range g.Clusters {
+		addBlock(&g.Clusters[i], g.Clusters[i].Nodes)
+	}
+	for _, node := range g.Nodes {
+		addBlock(nil, []diagramNode{node})
+	}
+	// Edges may reference nodes that are not declared, like DOT creates them implicitly
+	for _, edge := range g.Edges {
+		for _, id := range []string{edge.From, edge.To} {
+			if l.blockOf[id] == nil {
+				addBlock(nil, []diagramNode{{ID: id, Label: id, Fill: colorFollower}})
+			}
+		}
+	}
+
+	l.assignLayers(g.Edges)
+	l.order(g.Edges)
+	l.place()
+	return l
+}
+
+// assignLayers places each block one column right of its furthest predecessor block
+func (l *svgLayout) assignLayers(edges []diagramEdge) {
+	type pair struct{ from, to *svgBlock }
+	seen := make(map[pair]bool)
+	var links []pair
+	for _, edge := range edges {
+		p := pair{l.blockOf[edge.From], l.blockOf[edge.To]}
+		if p.from != p.to && !seen[p] {
+			seen[p] = true
+			links = append(links, p)
+		}
+	}
+
+	// Relax until stable; the bound stops cycles from growing forever
+	for i := 0; i < len(l.blocks); i++ {
+		changed := false
+		for _, p := range links {
+			if p.to.layer < p.from.layer+1 && p.from.layer+1 < len(l.blocks) {
+				p.to.layer = p.from.layer + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+}
+
+// order sorts nodes and blocks column by column by the mean position of their predecessors
+func (l *svgLayout) order(edges []diagramEdge) {
+	preds := make(map[string][]string)
+	for _, edge := range edges {
+		preds[edge.To] = append(preds[edge.To], edge.From)
+	}
+
+	position := make(map[string]float64)
+	for _, layer := range l.layers() {
+		for _, b := range layer {
+			bary := make(map[string]float64, len(b.nodes))
+			var sum float64
+			var count int
+			for i, node := range b.nodes {
+				var s float64
+				var n int
+				for _, p := range preds[node.ID] {
+					if pos, ok := position[p]; ok && l.blockOf[p].layer < b.layer {
+						s += pos
+						n++
+					}
+				}
+				if n > 0 {
+					bary[node.ID] = s / float64(n)
+					sum += bary[node.ID]
+					count++
+				} else {
+					// Nodes without placed predecessors keep their original order at the end
+					bary[node.ID] = 1e9 + float64(i)
+				}
+			}
+			sort.SliceStable(b.nodes, func(i, j int) bool {
+				return bary[b.nodes[i].ID] < bary[b.nodes[j].ID]
+			})
+			b.order = 1e9 + float64(b.index)
+			if count > 0 {
+				b.order = sum / float64(count)
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool { return layer[i].order < layer[j].order })
+
+		var pos float64
+		for _, b := range layer {
+			for _, node := range b.nodes {
+				position[node.ID] = pos
+				pos++
+			}
+		}
+	}
+}
+
+// layers returns the blocks per column, in block order
+func (l *svgLayout) layers() [][]*svgBlock {
+	var layers [][]*svgBlock
+	for _, b := range l.blocks {
+		for len(layers) <= b.layer {
+			layers = append(layers, nil)
+		}
+		layers[b.layer] = append(layers[b.layer], b)
+	}
+	return layers
+}
+
+func textWidth(s string) float64 {
+	return float64(len([]rune(s))) * svgCharWidth
+}
+
+// place computes block and node coordinates; shorter columns are centered vertically
+func (l *svgLayout) place() {
+	layers := l.layers()
+	for _, layer := range layers {
+		sort.SliceStable(layer, func(i, j int) bool { return layer[i].order < layer[j].order })
+	}
+
+	x := svgMargin
+	columnHeights := make([]float64, len(layers))
+	for col, layer := range layers {
+		var columnWidth float64
+		for _, b := range layer {
+			nodeWidth := svgNodeMinWidth
+			for _, node := range b.nodes {
+				nodeWidth = maxFloat(nodeWidth, textWidth(truncateLabel(node.Label))+24, textWidth(node.Detail)+24)
+			}
+			b.box.w = nodeWidth
+			b.box.h = float64(len(b.nodes))*(svgNodeHeight+svgNodeGap) - svgNodeGap
+			if b.cluster != nil {
+				b.box.w = maxFloat(nodeWidth, textWidth(b.cluster.Label)) + 2*svgClusterPad
+				b.box.h += svgClusterTitle + 2*svgClusterPad
+			}
+			columnWidth = maxFloat(columnWidth, b.box.w)
+			columnHeights[col] += b.box.h + svgBlockGap
+		}
+		columnHeights[col] -= svgBlockGap
+
+		for _, b := range layer {
+			b.box.x = x + (columnWidth-b.box.w)/2
+		}
+		x += columnWidth + svgColumnGap
+	}
+	l.width = x - svgColumnGap + svgMargin
+
+	var maxHeight float64
+	for _, h := range columnHeights {
+		maxHeight = maxFloat(maxHeight, h)
+	}
+	l.height = maxHeight + 2*svgMargin
+
+	for col, layer := range layers {
+		y := svgMargin + (maxHeight-columnHeights[col])/2
+		for _, b := range layer {
+			b.box.y = y
+			nodeX, nodeY, nodeW := b.box.x, y, b.box.w
+			if b.cluster != nil {
+				nodeX += svgClusterPad
+				nodeY += svgClusterTitle + svgClusterPad
+				nodeW -= 2 * svgClusterPad
+			}
+			for _, node := range b.nodes {
+				l.nodes[node.ID] = svgBox{x: nodeX, y: nodeY, w: nodeW, h: svgNodeHeight}
+				nodeY += svgNodeHeight + svgNodeGap
+			}
+			y += b.box.h + svgBlockGap
+		}
+	}
+}
+
+func maxFloat(values ...float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// renderSVG lays out g and renders it as a standalone SVG document
+func renderSVG(g *diagramGraph) string {
+	l := layoutSVG(g)
+	var svg strings.Builder
+	esc := html.EscapeString
+
+	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="-apple-system, 'Segoe UI', Roboto, sans-serif" font-size="12">`+"\n",
+		l.width, l.height, l.width, l.height))
+
+	// One arrowhead per edge color
+	markers := make(map[string]string)
+	svg.WriteString("<defs>\n")
+	for _, edge := range g.Edges {
+		if _, ok := markers[edge.Color]; !ok {
+			markers[edge.Color] = fmt.Sprintf("arrow%d", len(markers))
+			svg.WriteString(fmt.Sprintf(`<marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker>`+"\n",
+				markers[edge.Color], esc(edge.Color)))
+		}
+	}
+	svg.WriteString("</defs>\n")
+
+	// Clusters
+	for _, b := range l.blocks {
+		if b.cluster == nil {
+			continue
+		}
+		svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="8" fill="%s"/>`+"\n",
+			b.box.x, b.box.y, b.box.w, b.box.h, esc(b.cluster.Color)))
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" font-size="14" font-weight="600" fill="#333">%s</text>`+"\n",
+			b.box.x+b.box.w/2, b.box.y+svgClusterPad+10, esc(b.cluster.Label)))
+	}
+
+	// Edges below nodes
+	for _, edge := range g.Edges {
+		from, to := l.nodes[edge.From], l.nodes[edge.To]
+		x1, y1 := from.x+from.w, from.y+from.h/2
+		x2, y2 := to.x, to.y+to.h/2
+		var path string
+		if l.blockOf[edge.To].layer > l.blockOf[edge.From].layer {
+			dx := (x2 - x1) / 2
+			path = fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", x1, y1, x1+dx, y1, x2-dx, y2, x2, y2)
+		} else {
+			// Same or earlier column: loop out to the right side of the target
+			x2 = to.x + to.w
+			bulge := maxFloat(x1, x2) + 60
+			path = fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", x1, y1, bulge, y1, bulge, y2, x2, y2)
+		}
+
+		width := 1.2
+		if edge.Bold {
+			width = 2
+		}
+		attrs := fmt.Sprintf(`stroke="%s" stroke-width="%.1f"`, esc(edge.Color), width)
+		if edge.Dashed {
+			attrs += ` stroke-dasharray="5,4"`
+		}
+		svg.WriteString(fmt.Sprintf(`<path d="%s" fill="none" %s marker-end="url(#%s)"/>`+"\n", path, attrs, markers[edge.Color]))
+		if edge.Label != "" {
+			svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" font-size="11" fill="#555">%s</text>`+"\n",
+				(x1+x2)/2, (y1+y2)/2-4, esc(edge.Label)))
+		}
+	}
+
+	// Nodes
+	for _, b := range l.blocks {
+		fill := ""
+		if b.cluster != nil {
+			fill = b.cluster.NodeFill
+		}
+		for _, node := range b.nodes {
+			box := l.nodes[node.ID]
+			nodeFill := fill
+			if node.Fill != "" {
+				nodeFill = node.Fill
+			}
+			stroke := `stroke="none"`
+			if node.Border != "" {
+				stroke = fmt.Sprintf(`stroke="%s" stroke-width="3"`, esc(node.Border))
+			}
+			title := node.Label
+			if node.Detail != "" {
+				title += " (" + node.Detail + ")"
+			}
+			svg.WriteString("<g>")
+			svg.WriteString(fmt.Sprintf("<title>%s</title>", esc(title)))
+			svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="6" fill="%s" %s/>`,
+				box.x, box.y, box.w, box.h, esc(nodeFill), stroke))
+			labelY := box.y + 17
+			if node.Detail == "" {
+				labelY = box.y + 24
+			}
+			svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" fill="white" font-weight="600">%s</text>`,
+				box.x+box.w/2, labelY, esc(truncateLabel(node.Label))))
+			if node.Detail != "" {
+				svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" fill="white" font-size="11">(%s)</text>`,
+					box.x+box.w/2, box.y+32, esc(node.Detail)))
+			}
+			svg.WriteString("</g>\n")
+		}
+	}
+
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
+
+// generateSVGFiles renders each view to SVG. A single view is written to filename,
+// several views to <filename>-<view>.svg. Returns the files written.
+func generateSVGFiles(info *KafkaClusterInfo, filename string, views []string) ([]string, error) {
+	var written []string
+	for _, view := range views {
+		output := filename
+		if len(views) > 1 {
+			output = exportFilename(filename, "-"+view, ".svg")
+		}
+		if err := os.WriteFile(output, []byte(renderSVG(buildView(info, view))), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, output)
+	}
+	return written, nil
+}
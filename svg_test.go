@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLayoutSVG(t *testing.T) {
+	g := &diagramGraph{
+		Clusters: []diagramCluster{
+			{ID: "topics", Label: "Topics", Nodes: []diagramNode{{ID: "t1", Label: "t1"}, {ID: "t2", Label: "t2"}}},
+			{ID: "groups", Label: "Groups", Nodes: []diagramNode{{ID: "g1", Label: "g1"}, {ID: "g2", Label: "g2"}}},
+		},
+		Nodes: []diagramNode{{ID: "lonely", Label: "lonely"}},
+		// "sink" is not declared and gets a block of its own
+		Edges: []diagramEdge{{From: "t2", To: "g1"}, {From: "t1", To: "g2"}, {From: "g1", To: "sink"}},
+	}
+	l := layoutSVG(g)
+
+	layers := map[string]int{"t1": 0, "t2": 0, "lonely": 0, "g1": 1, "g2": 1, "sink": 2}
+	for id, want := range layers {
+		if got := l.blockOf[id].layer; got != want {
+			t.Errorf("layer of %s = %d, want %d", id, got, want)
+		}
+	}
+
+	// g2 follows t1 and moves above g1; the shorter columns are centered vertically
+	nodes := map[string]svgBox{
+		"t1":     {x: 34, y: 56, w: 120, h: 40},
+		"t2":     {x: 34, y: 108, w: 120, h: 40},
+		"lonely": {x: 34, y: 186, w: 120, h: 40},
+		"g2":     {x: 322, y: 88, w: 120, h: 40},
+		"g1":     {x: 322, y: 140, w: 120, h: 40},
+		"sink":   {x: 596, y: 103, w: 120, h: 40},
+	}
+	for id, want := range nodes {
+		if got := l.nodes[id]; got != want {
+			t.Errorf("box of %s = %+v, want %+v", id, got, want)
+		}
+	}
+	if l.width != 736 || l.height != 246 {
+		t.Errorf("size = %vx%v, want 736x246", l.width, l.height)
+	}
+}
+
+func TestLayoutSVGCycle(t *testing.T) {
+	g := &diagramGraph{
+		Nodes: []diagramNode{{ID: "a", Label: "a"}, {ID: "b", Label: "b"}},
+		Edges: []diagramEdge{{From: "a", To: "b"}, {From: "b", To: "a"}, {From: "a", To: "a"}},
+	}
+	l := layoutSVG(g)
+	for _, b := range l.blocks {
+		if b.layer >= len(l.blocks) {
+			t.Errorf("block %s in layer %d, want below %d", b.nodes[0].ID, b.layer, len(l.blocks))
+		}
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	got := renderSVG(buildTopologyGraph(testTopologyCluster()))
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="499" height="246" viewBox="0 0 499 246" font-family="-apple-system, 'Segoe UI', Roboto, sans-serif" font-size="12">
+<defs>
+<marker id="arrow0" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#667eea"/></marker>
+</defs>
+<rect x="20.0" y="20.0" width="148.0" height="142.0" rx="8" fill="lightgrey"/>
+<text x="94.0" y="44.0" text-anchor="middle" font-size="14" font-weight="600" fill="#333">Topics</text>
+<rect x="308.0" y="52.0" width="171.0" height="142.0" rx="8" fill="lightblue"/>
+<text x="393.5" y="76.0" text-anchor="middle" font-size="14" font-weight="600" fill="#333">Consumer Groups</text>
+<path d="M154.0,76.0 C238.0,76.0 238.0,108.0 322.0,108.0" fill="none" stroke="#667eea" stroke-width="2.0" marker-end="url(#arrow0)"/>
+<path d="M154.0,128.0 C238.0,128.0 238.0,108.0 322.0,108.0" fill="none" stroke="#667eea" stroke-width="2.0" marker-end="url(#arrow0)"/>
+<path d="M154.0,206.0 C238.0,206.0 238.0,108.0 322.0,108.0" fill="none" stroke="#667eea" stroke-width="2.0" marker-end="url(#arrow0)"/>
+<g><title>orders-v1 (2 partitions)</title><rect x="34.0" y="56.0" width="120.0" height="40.0" rx="6" fill="#667eea" stroke="none"/><text x="94.0" y="73.0" text-anchor="middle" fill="white" font-weight="600">orders-v1</text><text x="94.0" y="88.0" text-anchor="middle" fill="white" font-size="11">(2 partitions)</text></g>
+<g><title>orders.v1 (1 partitions)</title><rect x="34.0" y="108.0" width="120.0" height="40.0" rx="6" fill="#667eea" stroke="none"/><text x="94.0" y="125.0" text-anchor="middle" fill="white" font-weight="600">orders.v1</text><text x="94.0" y="140.0" text-anchor="middle" fill="white" font-size="11">(1 partitions)</text></g>
+<g><title>billing (2 members, Stable)</title><rect x="322.0" y="88.0" width="143.0" height="40.0" rx="6" fill="#43e97b" stroke="none"/><text x="393.5" y="105.0" text-anchor="middle" fill="white" font-weight="600">billing</text><text x="393.5" y="120.0" text-anchor="middle" fill="white" font-size="11">(2 members, Stable)</text></g>
+<g><title>billing&#34;svc (0 members, Empty)</title><rect x="322.0" y="140.0" width="143.0" height="40.0" rx="6" fill="#43e97b" stroke="none"/><text x="393.5" y="157.0" text-anchor="middle" fill="white" font-weight="600">billing&#34;svc</text><text x="393.5" y="172.0" text-anchor="middle" fill="white" font-size="11">(0 members, Empty)</text></g>
+<g><title>topic_audit</title><rect x="34.0" y="186.0" width="120.0" height="40.0" rx="6" fill="#999999" stroke="none"/><text x="94.0" y="210.0" text-anchor="middle" fill="white" font-weight="600">topic_audit</text></g>
+</svg>
+`
+	if got != want {
+		t.Errorf("SVG output:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Names are escaped, so the document stays well-formed
+	decoder := xml.NewDecoder(strings.NewReader(got))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+	}
+}
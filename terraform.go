@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// generateTerraformFiles writes kafka_topic and kafka_acl resources for the Mongey/kafka provider
+// to filename, and matching import blocks (Terraform 1.5+) to <filename>_imports.tf so existing
+// topics and ACLs can be adopted without being recreated. Overrides, if not nil, change the topics.
+func generateTerraformFiles(info *KafkaClusterInfo, filename string, overrides *TopicOverrides) (string, error) {
+	var tf, imports strings.Builder
+	names := newIdentifierNamer()
+
+	// Header
+	tf.WriteString("# Kafka Terraform Export\n")
+	tf.WriteString(fmt.Sprintf("# Generated: %s\n", info.Timestamp))
+	tf.WriteString(fmt.Sprintf("# Source Cluster: %s\n", strings.Join(info.Brokers, ", ")))
+	tf.WriteString("# Provider: Mongey/kafka\n")
+	tf.WriteString(fmt.Sprintf("# Internal topics (starting with __) skipped: %d\n\n", countInternalTopics(info.Topics)))
+
+	tf.WriteString("terraform {\n")
+	tf.WriteString("  required_providers {\n")
+	tf.WriteString("    kafka = {\n")
+	tf.WriteString("      source = \"Mongey/kafka\"\n")
+	tf.WriteString("    }\n")
+	tf.WriteString("  }\n")
+	tf.WriteString("}\n\n")
+
+	tf.WriteString("# Configure the provider for the target cluster, e.g.:\n")
+	tf.WriteString("# provider \"kafka\" {\n")
+	tf.WriteString(fmt.Sprintf("#   bootstrap_servers = [%s]\n", hclStringList(info.Brokers)))
+	tf.WriteString("# }\n\n")
+
+	imports.WriteString("# Import blocks for adopting existing resources (Terraform 1.5+)\n")
+	imports.WriteString(fmt.Sprintf("# Generated: %s\n", info.Timestamp))
+	imports.WriteString("# Run terraform plan, check that no resources are replaced, then apply.\n")
+	imports.WriteString("# This file can be removed once the resources are in the state.\n")
+	if overrides != nil {
+		imports.WriteString("# Topic overrides were applied: the plan changes the imported topics accordingly.\n")
+	}
+	imports.WriteString("\n")
+
+	// Topics
+	for _, topic := range info.Topics {
+		// Skip internal topics (starting with __)
+		if strings.HasPrefix(topic.Name, "__") {
+			continue
+		}
+
+		topic, notes := overrides.apply(topic)
+		name := names.name("", topic.Name)
+		for _, note := range notes {
+			tf.WriteString(fmt.Sprintf("# override: %s\n", note))
+		}
+		tf.WriteString(fmt.Sprintf("resource \"kafka_topic\" \"%s\" {\n", name))
+		tf.WriteString(fmt.Sprintf("  name               = %s\n", hclString(topic.Name)))
+		tf.WriteString(fmt.Sprintf("  partitions         = %d\n", topic.Partitions))
+		tf.WriteString(fmt.Sprintf("  replication_factor = %d\n", topic.ReplicationFactor))
+
+		if len(topic.Configs) > 0 {
+			tf.WriteString("\n  config = {\n")
+			width := 0
+			for k := range topic.Configs {
+				if len(hclString(k)) > width {
+					width = len(hclString(k))
+				}
+			}
+			for _, pair := range sortedConfigs(topic.Configs) {
+				k, v, _ := strings.Cut(pair, "=")
+				tf.WriteString(fmt.Sprintf("    %-*s = %s\n", width, hclString(k), hclString(v)))
+			}
+			tf.WriteString("  }\n")
+		}
+		tf.WriteString("}\n\n")
+
+		imports.WriteString("import {\n")
+		imports.WriteString(fmt.Sprintf("  to = kafka_topic.%s\n", name))
+		imports.WriteString(fmt.Sprintf("  id = %s\n", hclString(topic.Name)))
+		imports.WriteString("}\n\n")
+	}
+
+	// ACLs
+	if len(info.ACLs) > 0 {
+		tf.WriteString("# ACLs\n\n")
+	}
+	for _, acl := range info.ACLs {
+		name := names.name("acl_", strings.Join([]string{acl.Principal, acl.PermissionType, acl.Operation, acl.ResourceType, acl.ResourceName}, "_"))
+		tf.WriteString(fmt.Sprintf("resource \"kafka_acl\" \"%s\" {\n", name))
+		tf.WriteString(fmt.Sprintf("  resource_name                = %s\n", hclString(acl.ResourceName)))
+		tf.WriteString(fmt.Sprintf("  resource_type                = %s\n", hclString(acl.ResourceType)))
+		tf.WriteString(fmt.Sprintf("  resource_pattern_type_filter = %s\n", hclString(acl.PatternType)))
+		tf.WriteString(fmt.Sprintf("  acl_principal                = %s\n", hclString(acl.Principal)))
+		tf.WriteString(fmt.Sprintf("  acl_host                     = %s\n", hclString(acl.Host)))
+		tf.WriteString(fmt.Sprintf("  acl_operation                = %s\n", hclString(acl.Operation)))
+		tf.WriteString(fmt.Sprintf("  acl_permission_type          = %s\n", hclString(acl.PermissionType)))
+		tf.WriteString("}\n\n")
+
+		// Import ID format: principal|host|operation|permission|resource type|resource name|pattern type
+		importID := strings.Join([]string{acl.Principal, acl.Host, acl.Operation, acl.PermissionType, acl.ResourceType, acl.ResourceName, acl.PatternType}, "|")
+		imports.WriteString("import {\n")
+		imports.WriteString(fmt.Sprintf("  to = kafka_acl.%s\n", name))
+		imports.WriteString(fmt.Sprintf("  id = %s\n", hclString(importID)))
+		imports.WriteString("}\n\n")
+	}
+
+	if err := os.WriteFile(filename, []byte(tf.String()), 0644); err != nil {
+		return "", err
+	}
+
+	importsFile := strings.TrimSuffix(filename, filepath.Ext(filename)) + "_imports.tf"
+	if err := os.WriteFile(importsFile, []byte(imports.String()), 0644); err != nil {
+		return "", err
+	}
+
+	return importsFile, nil
+}
+
+// identifierNamer produces unique identifiers that are valid in Terraform, DOT, Mermaid and D2
+type identifierNamer struct {
+	used map[string]int
+}
+
+func newIdentifierNamer() *identifierNamer {
+	return &identifierNamer{used: make(map[string]int)}
+}
+
+var identifierInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// name converts s into an identifier ([A-Za-z_][A-Za-z0-9_]*) and appends a counter on collisions,
+// e.g. "orders.v1" and "orders-v1" become orders_v1 and orders_v1_2
+func (n *identifierNamer) name(prefix, s string) string {
+	base := prefix + strings.Trim(identifierInvalidChars.ReplaceAllString(s, "_"), "_")
+	if base == "" {
+		base = "resource"
+	}
+	if base[0] >= '0' && base[0] <= '9' {
+		base = "_" + base
+	}
+
+	n.used[base]++
+	if n.used[base] == 1 {
+		return base
+	}
+	name := fmt.Sprintf("%s_%d", base, n.used[base])
+	for n.used[name] > 0 {
+		n.used[base]++
+		name = fmt.Sprintf("%s_%d", base, n.used[base])
+	}
+	n.used[name]++
+	return name
+}
+
+// hclString quotes s as an HCL string literal, escaping template sequences
+func hclString(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	)
+	return `"` + r.Replace(s) + `"`
+}
+
+func hclStringList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = hclString(item)
+	}
+	return strings.Join(quoted, ", ")
+}
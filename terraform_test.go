@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTerraformFilesOverrides(t *testing.T) {
+	overrides, err := loadTopicOverrides("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides.Partitions.Min = 12
+
+	info := &KafkaClusterInfo{Topics: []TopicInfo{{
+		Name:              "orders",
+		Partitions:        6,
+		ReplicationFactor: 3,
+		Configs:           map[string]string{"message.format.version": "2.8", "cleanup.policy": "compact"},
+	}}}
+	filename := filepath.Join(t.TempDir(), "kafka.tf")
+	if _, err := generateTerraformFiles(info, filename, overrides); err != nil {
+		t.Fatalf("generateTerraformFiles: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf := string(data)
+	for _, want := range []string{
+		"# override: partitions 6 -> 12\n",
+		"partitions         = 12\n",
+		"replication_factor = 3\n",
+		`"cleanup.policy" = "compact"`,
+	} {
+		if !strings.Contains(tf, want) {
+			t.Errorf("Terraform output does not contain %q:\n%s", want, tf)
+		}
+	}
+	if strings.Contains(tf, `"message.format.version" =`) {
+		t.Errorf("denied config was exported:\n%s", tf)
+	}
+}
+
+func TestGenerateTerraformFilesWithoutOverrides(t *testing.T) {
+	configs := map[string]string{
+		"message.format.version":                "2.8",
+		"leader.replication.throttled.replicas": "0:1",
+		"confluent.placement.constraints":       "{}",
+		"cleanup.policy":                        "compact",
+	}
+	info := &KafkaClusterInfo{Topics: []TopicInfo{{Name: "orders", Partitions: 6, ReplicationFactor: 3, Configs: configs}}}
+	filename := filepath.Join(t.TempDir(), "kafka.tf")
+	importsFile, err := generateTerraformFiles(info, filename, nil)
+	if err != nil {
+		t.Fatalf("generateTerraformFiles: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf := string(data)
+	for name, value := range configs {
+		want := regexp.MustCompile(regexp.QuoteMeta(hclString(name)) + ` += ` + regexp.QuoteMeta(hclString(value)))
+		if !want.MatchString(tf) {
+			t.Errorf("Terraform output does not contain %s = %s:\n%s", name, value, tf)
+		}
+	}
+	if strings.Contains(tf, "# override:") {
+		t.Errorf("unexpected override comment:\n%s", tf)
+	}
+
+	imports, err := os.ReadFile(importsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(imports), "overrides") {
+		t.Errorf("imports mention overrides without any:\n%s", imports)
+	}
+}
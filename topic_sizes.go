@@ -19,17 +19,40 @@ type TopicSize struct {
 	TotalSize    int64  `json:"total_size_bytes"`
 	TotalSizeStr string `json:"total_size_human"`
 	Partitions   int    `json:"partitions"`
+
+	// PartitionSizes is the size of the largest replica of each partition
+	PartitionSizes map[int32]int64 `json:"partition_sizes,omitempty"`
 }
 
 // TopicSizesReport represents the complete size report
 type TopicSizesReport struct {
-	Timestamp       string      `json:"timestamp"`
-	Cluster         string      `json:"cluster"`
-	Topics          []TopicSize `json:"topics"`
-	TotalSize       int64       `json:"total_size_bytes"`
-	TotalSizeStr    string      `json:"total_size_human"`
-	TotalTopics     int         `json:"total_topics"`
-	TotalPartitions int         `json:"total_partitions"`
+	Timestamp       string       `json:"timestamp"`
+	Cluster         string       `json:"cluster"`
+	Topics          []TopicSize  `json:"topics"`
+	TotalSize       int64        `json:"total_size_bytes"`
+	TotalSizeStr    string       `json:"total_size_human"`
+	TotalTopics     int          `json:"total_topics"`
+	TotalPartitions int          `json:"total_partitions"`
+	Brokers         []BrokerSize `json:"brokers,omitempty"`
+}
+
+// BrokerSize is the disk usage of one broker for the topics in the report, per log directory and per topic
+type BrokerSize struct {
+	Broker    int32            `json:"broker"`
+	TotalSize int64            `json:"total_size_bytes"`
+	LogDirs   map[string]int64 `json:"log_dirs,omitempty"`
+	Topics    map[string]int64 `json:"topics"`
+}
+
+// add records the size of one partition replica
+func (b *BrokerSize) add(logDir, topic string, size int64) {
+	if b.LogDirs == nil {
+		b.LogDirs = make(map[string]int64)
+		b.Topics = make(map[string]int64)
+	}
+	b.TotalSize += size
+	b.LogDirs[logDir] += size
+	b.Topics[topic] += size
 }
 
 // DescribeLogDirsResponse represents the response from DescribeLogDirs API
@@ -79,11 +102,28 @@ func getTopicSizes(brokers []string, config *sarama.Config, topicFilter []string
 	// Map to store topic sizes (topic -> total size)
 	topicSizes := make(map[string]int64)
 	topicPartitions := make(map[string]map[int32]bool) // Track unique partitions per topic
+	partitionSizes := make(map[string]map[int32]int64) // Largest replica per partition
+
+	var brokerSizes []BrokerSize
+
+	// Only describe the requested topics; the request lists their partitions
+	describeTopics := []sarama.DescribeLogDirsRequestTopic{}
+	for _, topic := range topicFilter {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			log.Printf("Warning: Could not get partitions for topic %s: %v", topic, err)
+			continue
+		}
+		describeTopics = append(describeTopics, sarama.DescribeLogDirsRequestTopic{Topic: topic, PartitionIDs: partitions})
+	}
+	if len(topicFilter) > 0 && len(describeTopics) == 0 {
+		return nil, fmt.Errorf("none of the requested topics exist")
+	}
 
 	// Query each broker
 	for _, broker := range brokerIDs {
 		log.Printf("Querying broker %d at %s...", broker.ID(), broker.Addr())
-		
+
 		// Ensure broker is connected
 		if ok, _ := broker.Connected(); !ok {
 			if err := broker.Open(config); err != nil {
@@ -95,12 +135,12 @@ func getTopicSizes(brokers []string, config *sarama.Config, topicFilter []string
 		// Create DescribeLogDirs request - Version 0, empty DescribeTopics means all topics
 		request := &sarama.DescribeLogDirsRequest{
 			Version:        0,
-			DescribeTopics: []sarama.DescribeLogDirsRequestTopic{},
+			DescribeTopics: describeTopics,
 		}
 
 		log.Printf("Sending DescribeLogDirs request to broker %d...", broker.ID())
 		response, err := broker.DescribeLogDirs(request)
-		
+
 		if err != nil {
 			log.Printf("Warning: Error querying log dirs from broker %d at %s: %v", broker.ID(), broker.Addr(), err)
 			continue
@@ -112,6 +152,7 @@ func getTopicSizes(brokers []string, config *sarama.Config, topicFilter []string
 			continue
 		}
 
+		brokerSize := BrokerSize{Broker: broker.ID()}
 		for _, logDirInfo := range response.LogDirs {
 			if logDirInfo.ErrorCode != sarama.ErrNoError {
 				log.Printf("Warning: Log directory error on broker %d: %v", broker.ID(), logDirInfo.ErrorCode)
@@ -130,15 +171,23 @@ func getTopicSizes(brokers []string, config *sarama.Config, topicFilter []string
 				// Initialize maps if needed
 				if _, exists := topicPartitions[topicName]; !exists {
 					topicPartitions[topicName] = make(map[int32]bool)
+					partitionSizes[topicName] = make(map[int32]int64)
 				}
 
 				// Partitions is a slice of DescribeLogDirsResponsePartition
 				for _, partitionInfo := range topicInfo.Partitions {
 					topicSizes[topicName] += partitionInfo.Size
 					topicPartitions[topicName][partitionInfo.PartitionID] = true
+					if partitionInfo.Size > partitionSizes[topicName][partitionInfo.PartitionID] {
+						partitionSizes[topicName][partitionInfo.PartitionID] = partitionInfo.Size
+					}
+					brokerSize.add(logDirInfo.Path, topicName, partitionInfo.Size)
 				}
 			}
 		}
+		if brokerSize.Topics != nil {
+			brokerSizes = append(brokerSizes, brokerSize)
+		}
 	}
 
 	if len(topicSizes) == 0 {
@@ -155,10 +204,11 @@ func getTopicSizes(brokers []string, config *sarama.Config, topicFilter []string
 	topics := make([]TopicSize, 0, len(topicSizes))
 	for topic, size := range topicSizes {
 		topics = append(topics, TopicSize{
-			Topic:        topic,
-			TotalSize:    size,
-			TotalSizeStr: formatBytes(size),
-			Partitions:   len(topicPartitions[topic]),
+			Topic:          topic,
+			TotalSize:      size,
+			TotalSizeStr:   formatBytes(size),
+			Partitions:     len(topicPartitions[topic]),
+			PartitionSizes: partitionSizes[topic],
 		})
 		report.TotalSize += size
 		report.TotalPartitions += len(topicPartitions[topic])
@@ -169,7 +219,10 @@ func getTopicSizes(brokers []string, config *sarama.Config, topicFilter []string
 		return topics[i].TotalSize > topics[j].TotalSize
 	})
 
+	sort.Slice(brokerSizes, func(i, j int) bool { return brokerSizes[i].Broker < brokerSizes[j].Broker })
+
 	report.Topics = topics
+	report.Brokers = brokerSizes
 	report.TotalTopics = len(topics)
 	report.TotalSizeStr = formatBytes(report.TotalSize)
 
@@ -228,6 +281,19 @@ func saveTopicSizesJSON(report *TopicSizesReport, filename string) error {
 	return nil
 }
 
+// loadTopicSizesReport reads a report saved with -topic-sizes-output
+func loadTopicSizesReport(filename string) (*TopicSizesReport, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading topic sizes report: %v", err)
+	}
+	var report TopicSizesReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error parsing topic sizes report %s: %v", filename, err)
+	}
+	return &report, nil
+}
+
 // formatBytes formats bytes to human-readable format (IEC units)
 func formatBytes(bytes int64) string {
 	const unit = 1024
@@ -245,6 +311,22 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f %s", float64(bytes)/float64(div), units[exp])
 }
 
+// listTopicNames returns the names of all topics in the cluster, sorted
+func listTopicNames(brokers []string, config *sarama.Config) ([]string, error) {
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating client: %v", err)
+	}
+	defer client.Close()
+
+	topics, err := client.Topics()
+	if err != nil {
+		return nil, fmt.Errorf("error listing topics: %v", err)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
 // contains checks if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -50,7 +50,7 @@ type KafkaPartitionInfo struct {
 }
 
 // getTopicSizesFromKafkaCLI uses kafka-log-dirs.sh to get topic sizes (KRaft-compatible)
-func getTopicSizesFromKafkaCLI(config *sarama.Config, brokers []string, topicList []string) (*TopicSizesReport, error) {
+func getTopicSizesFromKafkaCLI(config *sarama.Config, opts SecurityOptions, brokers []string, topicList []string) (*TopicSizesReport, error) {
 	// Find kafka-log-dirs.sh in PATH or common locations
 	kafkaLogDirsPath, err := findKafkaLogDirs()
 	if err != nil {
@@ -71,7 +71,7 @@ func getTopicSizesFromKafkaCLI(config *sarama.Config, brokers []string, topicLis
 	}
 
 	// Create temporary config file if authentication is needed
-	configFile, cleanup, err := createKafkaConfigFile(config)
+	configFile, cleanup, err := createKafkaConfigFile(config, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config file: %v", err)
 	}
@@ -85,6 +85,10 @@ func getTopicSizesFromKafkaCLI(config *sarama.Config, brokers []string, topicLis
 	// Execute kafka-log-dirs.sh
 	log.Printf("Executing: %s %s", kafkaLogDirsPath, strings.Join(args, " "))
 	cmd := exec.Command(kafkaLogDirsPath, args...)
+	if config.Net.SASL.Enable && config.Net.SASL.Mechanism == sarama.SASLTypeGSSAPI {
+		// The JVM does not read krb5.conf from the properties file
+		cmd.Env = append(os.Environ(), "KAFKA_OPTS="+strings.TrimSpace(os.Getenv("KAFKA_OPTS")+" "+kerberosJavaOpts(config.Net.SASL.GSSAPI)))
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -159,64 +163,29 @@ func findKafkaLogDirs() (string, error) {
 }
 
 // createKafkaConfigFile creates a temporary properties file for authentication
-func createKafkaConfigFile(config *sarama.Config) (string, func(), error) {
+func createKafkaConfigFile(config *sarama.Config, opts SecurityOptions) (string, func(), error) {
 	if config.Net.SASL.Enable == false && config.Net.TLS.Enable == false {
 		// No auth needed
 		return "", nil, nil
 	}
 
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "kafka-config-*.properties")
+	// Use a private directory so the PEM keystore is cleaned up with the properties file
+	tmpDir, err := os.MkdirTemp("", "kafka-config-")
 	if err != nil {
 		return "", nil, err
 	}
 
 	cleanup := func() {
-		os.Remove(tmpFile.Name())
-	}
-
-	var lines []string
-
-	// Add SASL configuration
-	if config.Net.SASL.Enable {
-		protocol := "SASL_PLAINTEXT"
-		if config.Net.TLS.Enable {
-			protocol = "SASL_SSL"
-		}
-		lines = append(lines, fmt.Sprintf("security.protocol=%s", protocol))
-		lines = append(lines, fmt.Sprintf("sasl.mechanism=%s", config.Net.SASL.Mechanism))
-
-		switch config.Net.SASL.Mechanism {
-		case "PLAIN":
-			lines = append(lines, fmt.Sprintf("sasl.jaas.config=org.apache.kafka.common.security.plain.PlainLoginModule required username=\"%s\" password=\"%s\";",
-				config.Net.SASL.User, config.Net.SASL.Password))
-		case "SCRAM-SHA-256", "SCRAM-SHA-512":
-			lines = append(lines, fmt.Sprintf("sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required username=\"%s\" password=\"%s\";",
-				config.Net.SASL.User, config.Net.SASL.Password))
-		}
-	} else if config.Net.TLS.Enable {
-		lines = append(lines, "security.protocol=SSL")
-	}
-
-	// Add TLS configuration if needed
-	if config.Net.TLS.Enable && config.Net.TLS.Config != nil {
-		// Note: For full TLS support, we'd need to handle certificates
-		// This basic implementation handles TLS without client certs
-		lines = append(lines, "ssl.endpoint.identification.algorithm=")
-	}
-
-	// Write configuration
-	if _, err := tmpFile.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
-		cleanup()
-		return "", nil, err
+		os.RemoveAll(tmpDir)
 	}
 
-	if err := tmpFile.Close(); err != nil {
+	configFile := filepath.Join(tmpDir, "client.properties")
+	if _, err := writeKafkaClientProperties(config, opts, configFile); err != nil {
 		cleanup()
 		return "", nil, err
 	}
 
-	return tmpFile.Name(), cleanup, nil
+	return configFile, cleanup, nil
 }
 
 // convertKafkaLogDirsToReport converts kafka-log-dirs.sh output to our report format
@@ -229,7 +198,9 @@ func convertKafkaLogDirsToReport(kafkaResponse KafkaLogDirsResponse, brokers []s
 	partitionRegex := regexp.MustCompile(`^(.+)-(\d+)$`)
 
 	// Aggregate sizes by topic
+	var brokerSizes []BrokerSize
 	for _, broker := range kafkaResponse.Brokers {
+		brokerSize := BrokerSize{Broker: int32(broker.Broker)}
 		for _, logDir := range broker.LogDirs {
 			if logDir.Error != nil {
 				log.Printf("Warning: Error in log dir %s on broker %d: %s", logDir.LogDir, broker.Broker, *logDir.Error)
@@ -254,9 +225,10 @@ func convertKafkaLogDirsToReport(kafkaResponse KafkaLogDirsResponse, brokers []s
 				// Initialize topic if not seen before
 				if _, exists := topicSizes[topicName]; !exists {
 					topicSizes[topicName] = &TopicSize{
-						Topic:      topicName,
-						TotalSize:  0,
-						Partitions: 0,
+						Topic:          topicName,
+						TotalSize:      0,
+						Partitions:     0,
+						PartitionSizes: make(map[int32]int64),
 					}
 					partitionCounts[topicName] = make(map[int]bool)
 				}
@@ -264,11 +236,19 @@ func convertKafkaLogDirsToReport(kafkaResponse KafkaLogDirsResponse, brokers []s
 				// Add size (each replica counts)
 				topicSizes[topicName].TotalSize += partition.Size
 
-				// Track unique partitions
+				// Track unique partitions and the largest replica of each
 				partitionCounts[topicName][partitionNum] = true
+				if partition.Size > topicSizes[topicName].PartitionSizes[int32(partitionNum)] {
+					topicSizes[topicName].PartitionSizes[int32(partitionNum)] = partition.Size
+				}
+				brokerSize.add(logDir.LogDir, topicName, partition.Size)
 			}
 		}
+		if brokerSize.Topics != nil {
+			brokerSizes = append(brokerSizes, brokerSize)
+		}
 	}
+	sort.Slice(brokerSizes, func(i, j int) bool { return brokerSizes[i].Broker < brokerSizes[j].Broker })
 
 	// Update partition counts
 	for topicName, partitions := range partitionCounts {
@@ -282,6 +262,7 @@ func convertKafkaLogDirsToReport(kafkaResponse KafkaLogDirsResponse, brokers []s
 		Topics:      []TopicSize{},
 		TotalSize:   0,
 		TotalTopics: len(topicSizes),
+		Brokers:     brokerSizes,
 	}
 
 	// Convert map to slice and calculate totals
@@ -304,7 +285,19 @@ func convertKafkaLogDirsToReport(kafkaResponse KafkaLogDirsResponse, brokers []s
 }
 
 // getTopicSizesViaCLI is the main entry point using kafka-log-dirs.sh
-func getTopicSizesViaCLI(config *sarama.Config, brokers []string, topicList []string) (*TopicSizesReport, error) {
+func getTopicSizesViaCLI(config *sarama.Config, opts SecurityOptions, brokers []string, topicList []string) (*TopicSizesReport, error) {
 	log.Println("Using kafka-log-dirs.sh for KRaft compatibility...")
-	return getTopicSizesFromKafkaCLI(config, brokers, topicList)
+	return getTopicSizesFromKafkaCLI(config, opts, brokers, topicList)
+}
+
+// collectTopicSizes gets topic sizes with kafka-log-dirs.sh (KRaft-compatible), falling back to
+// the Sarama API (works on ZooKeeper-based Kafka)
+func collectTopicSizes(config *sarama.Config, opts SecurityOptions, brokers []string, topicList []string) (*TopicSizesReport, error) {
+	log.Println("Attempting to use kafka-log-dirs.sh for KRaft compatibility...")
+	report, err := getTopicSizesViaCLI(config, opts, brokers, topicList)
+	if err != nil {
+		log.Printf("kafka-log-dirs.sh failed (%v), falling back to Sarama API...", err)
+		report, err = getTopicSizes(brokers, config, topicList)
+	}
+	return report, err
 }
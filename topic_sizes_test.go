@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestGetTopicSizesDescribesOnlyRequestedTopics(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()).
+			SetLeader("orders", 1, broker.BrokerID()).
+			SetLeader("payments", 0, broker.BrokerID()),
+		"DescribeLogDirsRequest": sarama.NewMockDescribeLogDirsResponse(t).
+			SetLogDirs("/data", map[string]int{"orders": 2, "payments": 1}),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	report, err := getTopicSizes([]string{broker.Addr()}, config, []string{"orders"})
+	if err != nil {
+		t.Fatalf("getTopicSizes: %v", err)
+	}
+
+	var described []sarama.DescribeLogDirsRequestTopic
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.DescribeLogDirsRequest); ok {
+			described = req.DescribeTopics
+		}
+	}
+	if len(described) != 1 || described[0].Topic != "orders" || len(described[0].PartitionIDs) != 2 {
+		t.Errorf("expected a request for orders partitions 0-1, got %+v", described)
+	}
+	if len(report.Topics) != 1 || report.Topics[0].Topic != "orders" || report.Topics[0].TotalSize != 2*1234 {
+		t.Errorf("unexpected topics: %+v", report.Topics)
+	}
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Supported values for -dot-views
+const (
+	ViewTopology = "topology"
+	ViewBrokers  = "brokers"
+	ViewReplicas = "replicas"
+	ViewPrefixes = "prefixes"
+)
+
+// Diagram colors, matching the HTML report
+const (
+	colorTopic    = "#667eea"
+	colorConsumer = "#43e97b"
+	colorWarning  = "#f44336"
+	colorFollower = "#999999"
+)
+
+// diagramNode is a box with a name and an optional detail line
+type diagramNode struct {
+	ID     string
+	Label  string
+	Detail string // e.g. "3 partitions"
+	Fill   string // overrides the cluster's node color
+	Border string // highlight border color
+}
+
+// diagramCluster groups nodes in a labeled box (a DOT cluster, Mermaid subgraph or D2 container)
+type diagramCluster struct {
+	ID       string
+	Label    string
+	Color    string // background
+	NodeFill string
+	Nodes    []diagramNode
+}
+
+type diagramEdge struct {
+	From   string
+	To     string
+	Label  string
+	Color  string
+	Dashed bool
+	Bold   bool
+}
+
+// diagramGraph is the format-independent graph behind the DOT, Mermaid and D2 exports,
+// so all formats share node IDs, subgraphs and edges
+type diagramGraph struct {
+	Clusters []diagramCluster
+	Nodes    []diagramNode // nodes outside any cluster
+	Edges    []diagramEdge
+}
+
+// clusterOf maps node IDs to the ID of their cluster
+func (g *diagramGraph) clusterOf() map[string]string {
+	clusters := make(map[string]string)
+	for _, cluster := range g.Clusters {
+		for _, node := range cluster.Nodes {
+			clusters[node.ID] = cluster.ID
+		}
+	}
+	return clusters
+}
+
+// parseViews parses the comma-separated -dot-views flag
+func parseViews(value string) ([]string, error) {
+	var views []string
+	seen := make(map[string]bool)
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		switch v {
+		case "":
+			continue
+		case ViewTopology, ViewBrokers, ViewReplicas, ViewPrefixes:
+		default:
+			return nil, fmt.Errorf("unknown view %q (supported: topology, brokers, replicas, prefixes)", v)
+		}
+		if !seen[v] {
+			seen[v] = true
+			views = append(views, v)
+		}
+	}
+	if len(views) == 0 {
+		views = []string{ViewTopology}
+	}
+	return views, nil
+}
+
+// buildView returns the graph for one of the -dot-views
+func buildView(info *KafkaClusterInfo, view string) *diagramGraph {
+	switch view {
+	case ViewBrokers:
+		return buildBrokerGraph(info)
+	case ViewReplicas:
+		return buildReplicaGraph(info)
+	case ViewPrefixes:
+		return buildPrefixGraph(info)
+	}
+	return buildTopologyGraph(info)
+}
+
+// buildTopologyGraph returns topics and consumer groups with subscription edges
+func buildTopologyGraph(info *KafkaClusterInfo) *diagramGraph {
+	names := newIdentifierNamer()
+	topicIDs := make(map[string]string)
+
+	// Identifiers are unique after sanitizing, so "a-b" and "a.b" get different nodes
+	topicID := func(name string) string {
+		if id, ok := topicIDs[name]; ok {
+			return id
+		}
+		topicIDs[name] = names.name("topic_", name)
+		return topicIDs[name]
+	}
+
+	topics := diagramCluster{ID: "cluster_topics", Label: "Topics", Color: "lightgrey", NodeFill: colorTopic}
+	for _, topic := range info.Topics {
+		topics.Nodes = append(topics.Nodes, diagramNode{
+			ID:     topicID(topic.Name),
+			Label:  topic.Name,
+			Detail: fmt.Sprintf("%d partitions", topic.Partitions),
+		})
+	}
+
+	// Edges can also point at subscribed topics that are not part of the topic list
+	groups := diagramCluster{ID: "cluster_consumers", Label: "Consumer Groups", Color: "lightblue", NodeFill: colorConsumer}
+	var edges []diagramEdge
+	for _, group := range info.ConsumerGroups {
+		id := names.name("consumer_", group.Name)
+		groups.Nodes = append(groups.Nodes, diagramNode{
+			ID:     id,
+			Label:  group.Name,
+			Detail: fmt.Sprintf("%d members, %s", group.Members, group.State),
+		})
+		for _, topic := range group.Topics {
+			edges = append(edges, diagramEdge{From: topicID(topic), To: id, Color: colorTopic, Bold: true})
+		}
+	}
+
+	return &diagramGraph{Clusters: []diagramCluster{topics, groups}, Edges: edges}
+}
+
+// brokerNodes returns a node per broker, grouped into one cluster per rack.
+// Brokers without a rack are returned separately.
+func brokerNodes(info *KafkaClusterInfo, detail func(BrokerInfo) string) ([]diagramCluster, []diagramNode, map[int32]string) {
+	ids := make(map[int32]string)
+	racks := make(map[string]*diagramCluster)
+	var rackNames []string
+	var unracked []diagramNode
+
+	for _, broker := range info.BrokerDetails {
+		node := diagramNode{
+			ID:     fmt.Sprintf("broker_%d", broker.ID),
+			Label:  fmt.Sprintf("Broker %d", broker.ID),
+			Detail: detail(broker),
+		}
+		if broker.UnderReplicated > 0 {
+			node.Fill = colorWarning
+		}
+		ids[broker.ID] = node.ID
+
+		if broker.Rack == "" {
+			// No cluster to inherit the node color from
+			if node.Fill == "" {
+				node.Fill = colorTopic
+			}
+			unracked = append(unracked, node)
+			continue
+		}
+		if racks[broker.Rack] == nil {
+			racks[broker.Rack] = &diagramCluster{
+				Label:    "Rack " + broker.Rack,
+				Color:    "lightyellow",
+				NodeFill: colorTopic,
+			}
+			rackNames = append(rackNames, broker.Rack)
+		}
+		racks[broker.Rack].Nodes = append(racks[broker.Rack].Nodes, node)
+	}
+
+	sort.Strings(rackNames)
+	names := newIdentifierNamer()
+	clusters := make([]diagramCluster, 0, len(rackNames))
+	for _, rack := range rackNames {
+		racks[rack].ID = names.name("cluster_rack_", rack)
+		clusters = append(clusters, *racks[rack])
+	}
+	return clusters, unracked, ids
+}
+
+// buildBrokerGraph shows brokers with partition and leader counts, racks as clusters
+// and brokers hosting under-replicated partitions in red
+func buildBrokerGraph(info *KafkaClusterInfo) *diagramGraph {
+	clusters, unracked, _ := brokerNodes(info, func(b BrokerInfo) string {
+		detail := fmt.Sprintf("%d partitions, %d leaders", b.Partitions, b.Leaders)
+		if b.UnderReplicated > 0 {
+			detail += fmt.Sprintf(", %d under-replicated", b.UnderReplicated)
+		}
+		return detail
+	})
+
+	return &diagramGraph{Clusters: clusters, Nodes: unracked}
+}
+
+// buildReplicaGraph draws each partition of the non-internal topics with edges to the brokers
+// holding its replicas: bold for the leader, dashed for followers, red for replicas out of ISR.
+// Partitions with several replicas in the same rack (when enough racks exist) get a red border.
+func buildReplicaGraph(info *KafkaClusterInfo) *diagramGraph {
+	rackClusters, unracked, brokerIDs := brokerNodes(info, func(b BrokerInfo) string {
+		return fmt.Sprintf("%d replicas, %d leaders", b.Partitions, b.Leaders)
+	})
+
+	rackOf := make(map[int32]string)
+	rackCount := make(map[string]bool)
+	for _, broker := range info.BrokerDetails {
+		if broker.Rack != "" {
+			rackOf[broker.ID] = broker.Rack
+			rackCount[broker.Rack] = true
+		}
+	}
+
+	brokerID := func(id int32) string {
+		if node, ok := brokerIDs[id]; ok {
+			return node
+		}
+		return fmt.Sprintf("broker_%d", id)
+	}
+
+	g := &diagramGraph{Nodes: unracked}
+	names := newIdentifierNamer()
+	for _, topic := range info.Topics {
+		if strings.HasPrefix(topic.Name, "__") || len(topic.PartitionDetails) == 0 {
+			continue
+		}
+
+		cluster := diagramCluster{
+			ID:       names.name("cluster_topic_", topic.Name),
+			Label:    topic.Name,
+			Color:    "lightgrey",
+			NodeFill: colorConsumer,
+		}
+		for _, p := range topic.PartitionDetails {
+			node := diagramNode{
+				ID:     names.name("partition_", fmt.Sprintf("%s_%d", topic.Name, p.ID)),
+				Label:  fmt.Sprintf("%s-%d", topic.Name, p.ID),
+				Detail: fmt.Sprintf("leader %d", p.Leader),
+			}
+			if rackViolation(p.Replicas, rackOf, len(rackCount)) {
+				node.Border = colorWarning
+				node.Detail += ", rack violation"
+			}
+			cluster.Nodes = append(cluster.Nodes, node)
+
+			inSync := make(map[int32]bool)
+			for _, id := range p.ISR {
+				inSync[id] = true
+			}
+			for _, replica := range p.Replicas {
+				edge := diagramEdge{From: node.ID, To: brokerID(replica), Color: colorFollower, Dashed: true}
+				if replica == p.Leader {
+					edge.Color, edge.Dashed, edge.Bold = colorTopic, false, true
+				}
+				if !inSync[replica] {
+					edge.Color = colorWarning
+				}
+				g.Edges = append(g.Edges, edge)
+			}
+		}
+		g.Clusters = append(g.Clusters, cluster)
+	}
+	g.Clusters = append(g.Clusters, rackClusters...)
+	return g
+}
+
+// rackViolation reports whether replicas share a rack although they could be spread
+// over distinct racks
+func rackViolation(replicas []int32, rackOf map[int32]string, racks int) bool {
+	if racks < 2 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, replica := range replicas {
+		rack, ok := rackOf[replica]
+		if !ok {
+			continue
+		}
+		if seen[rack] && len(replicas) <= racks {
+			return true
+		}
+		seen[rack] = true
+	}
+	return false
+}
+
+// topicPrefix returns the first segment of a topic name, split at '.', '-' or '_'
+func topicPrefix(name string) string {
+	if strings.HasPrefix(name, "__") {
+		return "__internal"
+	}
+	if i := strings.IndexAny(name, ".-_"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// buildPrefixGraph collapses topics by naming prefix, so large clusters stay readable.
+// Consumer groups get one edge per prefix, labeled with the number of subscribed topics.
+func buildPrefixGraph(info *KafkaClusterInfo) *diagramGraph {
+	type prefixStats struct {
+		topics, partitions int
+		messages           int64
+	}
+
+	stats := make(map[string]*prefixStats)
+	var prefixes []string
+	for _, topic := range info.Topics {
+		prefix := topicPrefix(topic.Name)
+		if stats[prefix] == nil {
+			stats[prefix] = &prefixStats{}
+			prefixes = append(prefixes, prefix)
+		}
+		stats[prefix].topics++
+		stats[prefix].partitions += topic.Partitions
+		stats[prefix].messages += topic.TotalMessages
+	}
+	sort.Strings(prefixes)
+
+	names := newIdentifierNamer()
+	prefixIDs := make(map[string]string)
+	prefixID := func(prefix string) string {
+		if id, ok := prefixIDs[prefix]; ok {
+			return id
+		}
+		prefixIDs[prefix] = names.name("prefix_", prefix)
+		return prefixIDs[prefix]
+	}
+
+	topics := diagramCluster{ID: "cluster_topics", Label: "Topic Prefixes", Color: "lightgrey", NodeFill: colorTopic}
+	for _, prefix := range prefixes {
+		s := stats[prefix]
+		label := prefix + "*"
+		if s.topics == 1 {
+			label = prefix
+		}
+		topics.Nodes = append(topics.Nodes, diagramNode{
+			ID:     prefixID(prefix),
+			Label:  label,
+			Detail: fmt.Sprintf("%d topics, %d partitions, %s messages", s.topics, s.partitions, formatCompact(s.messages)),
+		})
+	}
+
+	groups := diagramCluster{ID: "cluster_consumers", Label: "Consumer Groups", Color: "lightblue", NodeFill: colorConsumer}
+	var edges []diagramEdge
+	for _, group := range info.ConsumerGroups {
+		id := names.name("consumer_", group.Name)
+		groups.Nodes = append(groups.Nodes, diagramNode{
+			ID:     id,
+			Label:  group.Name,
+			Detail: fmt.Sprintf("%d members, %s", group.Members, group.State),
+		})
+
+		counts := make(map[string]int)
+		var subscribed []string
+		for _, topic := range group.Topics {
+			prefix := topicPrefix(topic)
+			if counts[prefix] == 0 {
+				subscribed = append(subscribed, prefix)
+			}
+			counts[prefix]++
+		}
+		for _, prefix := range subscribed {
+			edge := diagramEdge{From: prefixID(prefix), To: id, Color: colorTopic, Bold: true}
+			if counts[prefix] > 1 {
+				edge.Label = fmt.Sprintf("%d topics", counts[prefix])
+			}
+			edges = append(edges, edge)
+		}
+	}
+
+	return &diagramGraph{Clusters: []diagramCluster{topics, groups}, Edges: edges}
+}
+
+// truncateLabel shortens long names so nodes stay readable
+func truncateLabel(name string) string {
+	runes := []rune(name)
+	if len(runes) > 30 {
+		return string(runes[:27]) + "..."
+	}
+	return name
+}
+
+// generateDOTFiles writes each view as a Graphviz DOT graph. A single view is written to
+// filename, several views to <filename>-<view>.dot. Returns the files written.
+func generateDOTFiles(info *KafkaClusterInfo, filename string, views []string) ([]string, error) {
+	var written []string
+	for _, view := range views {
+		output := filename
+		if len(views) > 1 {
+			output = exportFilename(filename, "-"+view, ".dot")
+		}
+		if err := os.WriteFile(output, []byte(dotGraph(buildView(info, view))), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, output)
+	}
+	return written, nil
+}
+
+func dotGraph(g *diagramGraph) string {
+	var dot strings.Builder
+
+	// DOT header
+	dot.WriteString("digraph KafkaCluster {\n")
+	dot.WriteString("  rankdir=LR;\n")
+	dot.WriteString("  node [shape=box, style=rounded];\n")
+	dot.WriteString("  graph [splines=true, overlap=false];\n\n")
+
+	for _, cluster := range g.Clusters {
+		dot.WriteString(fmt.Sprintf("  // %s\n", strings.ReplaceAll(cluster.Label, "\n", " ")))
+		dot.WriteString(fmt.Sprintf("  subgraph %s {\n", cluster.ID))
+		dot.WriteString(fmt.Sprintf("    label=\"%s\";\n", dotEscape(cluster.Label)))
+		dot.WriteString("    style=filled;\n")
+		dot.WriteString(fmt.Sprintf("    color=%s;\n", cluster.Color))
+		dot.WriteString(fmt.Sprintf("    node [style=filled, fillcolor=\"%s\", fontcolor=white];\n", cluster.NodeFill))
+		for _, node := range cluster.Nodes {
+			dot.WriteString("    " + dotNode(node) + "\n")
+		}
+		dot.WriteString("  }\n\n")
+	}
+
+	if len(g.Nodes) > 0 {
+		for _, node := range g.Nodes {
+			dot.WriteString("  " + dotNode(node) + "\n")
+		}
+		dot.WriteString("\n")
+	}
+
+	// Edges (connections)
+	dot.WriteString("  // Edges\n")
+	for _, edge := range g.Edges {
+		attrs := []string{fmt.Sprintf("color=\"%s\"", edge.Color)}
+		if edge.Bold {
+			attrs = append(attrs, "penwidth=2.0")
+		}
+		if edge.Dashed {
+			attrs = append(attrs, "style=dashed")
+		}
+		if edge.Label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=\"%s\"", dotEscape(edge.Label)))
+		}
+		dot.WriteString(fmt.Sprintf("  %s -> %s [%s];\n", edge.From, edge.To, strings.Join(attrs, ", ")))
+	}
+
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+func dotNode(node diagramNode) string {
+	attrs := []string{fmt.Sprintf("label=\"%s\\n(%s)\"", dotEscape(truncateLabel(node.Label)), dotEscape(node.Detail))}
+	if node.Fill != "" {
+		attrs = append(attrs, fmt.Sprintf("style=filled, fillcolor=\"%s\", fontcolor=white", node.Fill))
+	}
+	if node.Border != "" {
+		attrs = append(attrs, fmt.Sprintf("color=\"%s\", penwidth=3.0", node.Border))
+	}
+	return fmt.Sprintf("%s [%s];", node.ID, strings.Join(attrs, ", "))
+}
+
+// generateMermaidFile writes the topology as a Mermaid flowchart
+func generateMermaidFile(info *KafkaClusterInfo, filename string) error {
+	g := buildTopologyGraph(info)
+	var mmd strings.Builder
+
+	mmd.WriteString("flowchart LR\n")
+	for i, cluster := range g.Clusters {
+		mmd.WriteString(fmt.Sprintf("  classDef c%d fill:%s,color:#fff,stroke:%s\n", i, cluster.NodeFill, cluster.NodeFill))
+	}
+	mmd.WriteString("\n")
+
+	for i, cluster := range g.Clusters {
+		mmd.WriteString(fmt.Sprintf("  subgraph %s[\"%s\"]\n", cluster.ID, mermaidEscape(cluster.Label)))
+		for _, node := range cluster.Nodes {
+			mmd.WriteString(fmt.Sprintf("    %s[\"%s<br/>(%s)\"]:::c%d\n", node.ID, mermaidEscape(truncateLabel(node.Label)), mermaidEscape(node.Detail), i))
+		}
+		mmd.WriteString("  end\n\n")
+	}
+
+	for _, edge := range g.Edges {
+		mmd.WriteString(fmt.Sprintf("  %s --> %s\n", edge.From, edge.To))
+	}
+	if len(g.Edges) > 0 {
+		mmd.WriteString(fmt.Sprintf("  linkStyle default stroke:%s,stroke-width:2px\n", colorTopic))
+	}
+
+	return os.WriteFile(filename, []byte(mmd.String()), 0644)
+}
+
+// generateD2File writes the topology as a D2 diagram
+func generateD2File(info *KafkaClusterInfo, filename string) error {
+	g := buildTopologyGraph(info)
+	var d2 strings.Builder
+
+	d2.WriteString("direction: right\n\n")
+	d2.WriteString("classes: {\n")
+	for i, cluster := range g.Clusters {
+		d2.WriteString(fmt.Sprintf("  c%d: {style: {fill: \"%s\"; font-color: white; border-radius: 6}}\n", i, cluster.NodeFill))
+	}
+	d2.WriteString("}\n\n")
+
+	for i, cluster := range g.Clusters {
+		d2.WriteString(fmt.Sprintf("%s: \"%s\" {\n", cluster.ID, d2Escape(cluster.Label)))
+		d2.WriteString(fmt.Sprintf("  style.fill: %s\n", cluster.Color))
+		for _, node := range cluster.Nodes {
+			d2.WriteString(fmt.Sprintf("  %s: \"%s\\n(%s)\" {class: c%d}\n", node.ID, d2Escape(truncateLabel(node.Label)), d2Escape(node.Detail), i))
+		}
+		d2.WriteString("}\n\n")
+	}
+
+	// Nodes live inside the containers, so edges use their full path
+	clusterOf := g.clusterOf()
+	path := func(id string) string {
+		if cluster, ok := clusterOf[id]; ok {
+			return cluster + "." + id
+		}
+		return id
+	}
+	for _, edge := range g.Edges {
+		d2.WriteString(fmt.Sprintf("%s -> %s: {style: {stroke: \"%s\"; stroke-width: 2}}\n", path(edge.From), path(edge.To), edge.Color))
+	}
+
+	return os.WriteFile(filename, []byte(d2.String()), 0644)
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// mermaidEscape replaces characters that end a quoted label or are read as markup with entity codes
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`#`, "#35;", `"`, "#quot;", `<`, "#lt;", `>`, "#gt;", "\n", "<br/>").Replace(s)
+}
+
+// d2Escape escapes a D2 double-quoted string, including $ which starts a variable substitution
+func d2Escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`).Replace(s)
+}
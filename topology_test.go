@@ -0,0 +1,247 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testTopologyCluster has two topics whose names collide after sanitizing, a group
+// subscribed to a topic missing from the topic list and a partition with both replicas in one rack
+func testTopologyCluster() *KafkaClusterInfo {
+	return &KafkaClusterInfo{
+		BrokerDetails: []BrokerInfo{
+			{ID: 1, Rack: "eu-1a", Partitions: 3, Leaders: 2},
+			{ID: 2, Rack: "eu-1a", Partitions: 2, Leaders: 1, UnderReplicated: 1},
+			{ID: 3, Rack: "eu-1b", Partitions: 1},
+			{ID: 4},
+		},
+		Topics: []TopicInfo{
+			{Name: "orders-v1", Partitions: 2, TotalMessages: 1500, PartitionDetails: []PartitionInfo{
+				{ID: 0, Leader: 1, Replicas: []int32{1, 2}, ISR: []int32{1, 2}},
+				{ID: 1, Leader: 2, Replicas: []int32{2, 3}, ISR: []int32{2}},
+			}},
+			{Name: "orders.v1", Partitions: 1, TotalMessages: 10, PartitionDetails: []PartitionInfo{
+				{ID: 0, Leader: 1, Replicas: []int32{1}, ISR: []int32{1}},
+			}},
+		},
+		ConsumerGroups: []ConsumerGroupInfo{
+			{Name: "billing", Topics: []string{"orders-v1", "orders.v1", "audit"}, Members: 2, State: "Stable"},
+			{Name: "billing\"svc", Topics: nil, Members: 0, State: "Empty"},
+		},
+	}
+}
+
+func TestBuildTopologyGraphIdentifiers(t *testing.T) {
+	// A literal name matching a generated suffix must not reuse that identifier
+	info := testTopologyCluster()
+	info.Topics = append(info.Topics, TopicInfo{Name: "orders_v1_2", Partitions: 1})
+	g := buildTopologyGraph(info)
+	var ids []string
+	for _, cluster := range g.Clusters {
+		for _, node := range cluster.Nodes {
+			ids = append(ids, node.ID)
+		}
+	}
+	want := []string{"topic_orders_v1", "topic_orders_v1_2", "topic_orders_v1_2_2", "consumer_billing", "consumer_billing_svc"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("node IDs = %v, want %v", ids, want)
+	}
+}
+
+func TestGenerateMermaidFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "topology.mmd")
+	if err := generateMermaidFile(testTopologyCluster(), filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `flowchart LR
+  classDef c0 fill:#667eea,color:#fff,stroke:#667eea
+  classDef c1 fill:#43e97b,color:#fff,stroke:#43e97b
+
+  subgraph cluster_topics["Topics"]
+    topic_orders_v1["orders-v1<br/>(2 partitions)"]:::c0
+    topic_orders_v1_2["orders.v1<br/>(1 partitions)"]:::c0
+  end
+
+  subgraph cluster_consumers["Consumer Groups"]
+    consumer_billing["billing<br/>(2 members, Stable)"]:::c1
+    consumer_billing_svc["billing#quot;svc<br/>(0 members, Empty)"]:::c1
+  end
+
+  topic_orders_v1 --> consumer_billing
+  topic_orders_v1_2 --> consumer_billing
+  topic_audit --> consumer_billing
+  linkStyle default stroke:#667eea,stroke-width:2px
+`
+	if string(got) != want {
+		t.Errorf("Mermaid output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateD2File(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "topology.d2")
+	if err := generateD2File(testTopologyCluster(), filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `direction: right
+
+classes: {
+  c0: {style: {fill: "#667eea"; font-color: white; border-radius: 6}}
+  c1: {style: {fill: "#43e97b"; font-color: white; border-radius: 6}}
+}
+
+cluster_topics: "Topics" {
+  style.fill: lightgrey
+  topic_orders_v1: "orders-v1\n(2 partitions)" {class: c0}
+  topic_orders_v1_2: "orders.v1\n(1 partitions)" {class: c0}
+}
+
+cluster_consumers: "Consumer Groups" {
+  style.fill: lightblue
+  consumer_billing: "billing\n(2 members, Stable)" {class: c1}
+  consumer_billing_svc: "billing\"svc\n(0 members, Empty)" {class: c1}
+}
+
+cluster_topics.topic_orders_v1 -> cluster_consumers.consumer_billing: {style: {stroke: "#667eea"; stroke-width: 2}}
+cluster_topics.topic_orders_v1_2 -> cluster_consumers.consumer_billing: {style: {stroke: "#667eea"; stroke-width: 2}}
+topic_audit -> cluster_consumers.consumer_billing: {style: {stroke: "#667eea"; stroke-width: 2}}
+`
+	if string(got) != want {
+		t.Errorf("D2 output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDOTViews(t *testing.T) {
+	tests := []struct {
+		view string
+		want string
+	}{
+		{ViewBrokers, `digraph KafkaCluster {
+  rankdir=LR;
+  node [shape=box, style=rounded];
+  graph [splines=true, overlap=false];
+
+  // Rack eu-1a
+  subgraph cluster_rack_eu_1a {
+    label="Rack eu-1a";
+    style=filled;
+    color=lightyellow;
+    node [style=filled, fillcolor="#667eea", fontcolor=white];
+    broker_1 [label="Broker 1\n(3 partitions, 2 leaders)"];
+    broker_2 [label="Broker 2\n(2 partitions, 1 leaders, 1 under-replicated)", style=filled, fillcolor="#f44336", fontcolor=white];
+  }
+
+  // Rack eu-1b
+  subgraph cluster_rack_eu_1b {
+    label="Rack eu-1b";
+    style=filled;
+    color=lightyellow;
+    node [style=filled, fillcolor="#667eea", fontcolor=white];
+    broker_3 [label="Broker 3\n(1 partitions, 0 leaders)"];
+  }
+
+  broker_4 [label="Broker 4\n(0 partitions, 0 leaders)", style=filled, fillcolor="#667eea", fontcolor=white];
+
+  // Edges
+}
+`},
+		{ViewReplicas, `digraph KafkaCluster {
+  rankdir=LR;
+  node [shape=box, style=rounded];
+  graph [splines=true, overlap=false];
+
+  // orders-v1
+  subgraph cluster_topic_orders_v1 {
+    label="orders-v1";
+    style=filled;
+    color=lightgrey;
+    node [style=filled, fillcolor="#43e97b", fontcolor=white];
+    partition_orders_v1_0 [label="orders-v1-0\n(leader 1, rack violation)", color="#f44336", penwidth=3.0];
+    partition_orders_v1_1 [label="orders-v1-1\n(leader 2)"];
+  }
+
+  // orders.v1
+  subgraph cluster_topic_orders_v1_2 {
+    label="orders.v1";
+    style=filled;
+    color=lightgrey;
+    node [style=filled, fillcolor="#43e97b", fontcolor=white];
+    partition_orders_v1_0_2 [label="orders.v1-0\n(leader 1)"];
+  }
+
+  // Rack eu-1a
+  subgraph cluster_rack_eu_1a {
+    label="Rack eu-1a";
+    style=filled;
+    color=lightyellow;
+    node [style=filled, fillcolor="#667eea", fontcolor=white];
+    broker_1 [label="Broker 1\n(3 replicas, 2 leaders)"];
+    broker_2 [label="Broker 2\n(2 replicas, 1 leaders)", style=filled, fillcolor="#f44336", fontcolor=white];
+  }
+
+  // Rack eu-1b
+  subgraph cluster_rack_eu_1b {
+    label="Rack eu-1b";
+    style=filled;
+    color=lightyellow;
+    node [style=filled, fillcolor="#667eea", fontcolor=white];
+    broker_3 [label="Broker 3\n(1 replicas, 0 leaders)"];
+  }
+
+  broker_4 [label="Broker 4\n(0 replicas, 0 leaders)", style=filled, fillcolor="#667eea", fontcolor=white];
+
+  // Edges
+  partition_orders_v1_0 -> broker_1 [color="#667eea", penwidth=2.0];
+  partition_orders_v1_0 -> broker_2 [color="#999999", style=dashed];
+  partition_orders_v1_1 -> broker_2 [color="#667eea", penwidth=2.0];
+  partition_orders_v1_1 -> broker_3 [color="#f44336", style=dashed];
+  partition_orders_v1_0_2 -> broker_1 [color="#667eea", penwidth=2.0];
+}
+`},
+		{ViewPrefixes, `digraph KafkaCluster {
+  rankdir=LR;
+  node [shape=box, style=rounded];
+  graph [splines=true, overlap=false];
+
+  // Topic Prefixes
+  subgraph cluster_topics {
+    label="Topic Prefixes";
+    style=filled;
+    color=lightgrey;
+    node [style=filled, fillcolor="#667eea", fontcolor=white];
+    prefix_orders [label="orders*\n(2 topics, 3 partitions, 1.51K messages)"];
+  }
+
+  // Consumer Groups
+  subgraph cluster_consumers {
+    label="Consumer Groups";
+    style=filled;
+    color=lightblue;
+    node [style=filled, fillcolor="#43e97b", fontcolor=white];
+    consumer_billing [label="billing\n(2 members, Stable)"];
+    consumer_billing_svc [label="billing\"svc\n(0 members, Empty)"];
+  }
+
+  // Edges
+  prefix_orders -> consumer_billing [color="#667eea", penwidth=2.0, label="2 topics"];
+  prefix_audit -> consumer_billing [color="#667eea", penwidth=2.0];
+}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.view, func(t *testing.T) {
+			if got := dotGraph(buildView(testTopologyCluster(), tt.view)); got != tt.want {
+				t.Errorf("%s view:\n%s\nwant:\n%s", tt.view, got, tt.want)
+			}
+		})
+	}
+}
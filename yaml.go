@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// marshalYAML renders v as YAML using its JSON encoding, so field names and
+// omitempty follow the json tags and struct field order is preserved.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := readYAMLNode(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeYAMLNode(&buf, node, 0)
+	return buf.Bytes(), nil
+}
+
+// yamlNode is an ordered JSON value: map keys keep their encoding order
+type yamlNode struct {
+	keys   []string
+	fields map[string]*yamlNode
+	items  []*yamlNode
+	isMap  bool
+	isList bool
+	scalar string
+}
+
+func readYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node := &yamlNode{isMap: true, fields: make(map[string]*yamlNode)}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				child, err := readYAMLNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key)
+				node.fields[key] = child
+			}
+			_, err := dec.Token() // closing }
+			return node, err
+		case '[':
+			node := &yamlNode{isList: true}
+			for dec.More() {
+				child, err := readYAMLNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, child)
+			}
+			_, err := dec.Token() // closing ]
+			return node, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	case string:
+		return &yamlNode{scalar: yamlQuote(t)}, nil
+	case json.Number:
+		return &yamlNode{scalar: t.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", tok)
+}
+
+func (n *yamlNode) empty() bool {
+	return (n.isMap && len(n.keys) == 0) || (n.isList && len(n.items) == 0)
+}
+
+func (n *yamlNode) emptyValue() string {
+	if n.isMap {
+		return "{}"
+	}
+	return "[]"
+}
+
+func writeYAMLNode(w io.Writer, n *yamlNode, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch {
+	case n.isMap:
+		for _, key := range n.keys {
+			child := n.fields[key]
+			writeYAMLEntry(w, pad+yamlQuote(key)+":", child, indent)
+		}
+	case n.isList:
+		for _, item := range n.items {
+			if item.isMap && !item.empty() {
+				// First key on the dash line, the rest aligned under it
+				for i, key := range item.keys {
+					prefix := pad + "  "
+					if i == 0 {
+						prefix = pad + "- "
+					}
+					writeYAMLEntry(w, prefix+yamlQuote(key)+":", item.fields[key], indent+1)
+				}
+				continue
+			}
+			writeYAMLEntry(w, pad+"-", item, indent)
+		}
+	default:
+		fmt.Fprintf(w, "%s%s\n", pad, n.scalar)
+	}
+}
+
+// writeYAMLEntry writes "prefix value" for scalars and empty collections, or prefix followed by a nested block
+func writeYAMLEntry(w io.Writer, prefix string, child *yamlNode, indent int) {
+	switch {
+	case child.empty():
+		fmt.Fprintf(w, "%s %s\n", prefix, child.emptyValue())
+	case child.isMap || child.isList:
+		fmt.Fprintf(w, "%s\n", prefix)
+		writeYAMLNode(w, child, indent+1)
+	default:
+		fmt.Fprintf(w, "%s %s\n", prefix, child.scalar)
+	}
+}
+
+// yamlNonString matches plain scalars that YAML 1.1 parsers read as numbers or timestamps:
+// hex, octal and binary integers, underscore and sexagesimal numbers, .inf/.nan and dates
+var yamlNonString = regexp.MustCompile(`^[-+]?(0[xX][0-9a-fA-F_]+|0[oO]?[0-7_]+|0[bB][01_]+|[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?|([0-9][0-9_]*)?\.?[0-9][0-9_]*([eE][-+]?[0-9]+)?|\.(inf|Inf|INF))$|^\.(nan|NaN|NAN)$|^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt ]|$)`)
+
+// yamlQuote returns s as a plain scalar when that is unambiguous, otherwise double-quoted
+func yamlQuote(s string) string {
+	if s == "" {
+		return `""`
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || yamlNonString.MatchString(s) {
+		return strconv.Quote(s)
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` ") || strings.HasSuffix(s, " ") {
+		return strconv.Quote(s)
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\t\r\\") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
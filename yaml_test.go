@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestYAMLQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"orders", "orders"},
+		{"orders.v1", "orders.v1"},
+		{"compact,delete", "compact,delete"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"No", `"No"`},
+		{"123", `"123"`},
+		{"1.5", `"1.5"`},
+		{"1e3", `"1e3"`},
+		{"0x10", `"0x10"`},
+		{"0X1F", `"0X1F"`},
+		{"0o17", `"0o17"`},
+		{"0755", `"0755"`},
+		{"0b101", `"0b101"`},
+		{"1_000", `"1_000"`},
+		{"1:30", `"1:30"`},
+		{"190:20:30.15", `"190:20:30.15"`},
+		{".inf", `".inf"`},
+		{"-.Inf", `"-.Inf"`},
+		{".NaN", `".NaN"`},
+		{"2024-01-02", `"2024-01-02"`},
+		{"2024-1-2", `"2024-1-2"`},
+		{"2024-01-02T03:04:05Z", `"2024-01-02T03:04:05Z"`},
+		{"2024-01-02 03:04:05", `"2024-01-02 03:04:05"`},
+		{"2024-01-02-orders", "2024-01-02-orders"},
+		{"0xorders", "0xorders"},
+		{"v1:2", "v1:2"},
+		{"-orders", `"-orders"`},
+		{"key: value", `"key: value"`},
+	}
+	for _, tt := range tests {
+		if got := yamlQuote(tt.in); got != tt.want {
+			t.Errorf("yamlQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}